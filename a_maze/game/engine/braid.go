@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"math"
+	"math/rand"
+)
+
+// cells are on odd coordinates, the walls between them on even ones.
+// A braid maze has loops instead of some of its dead ends: each removed
+// dead end gets a passage to one of its neighbours, preferring the ones
+// that are dead ends too, so a single passage removes both
+func (m *Game) braid(fraction float64, rng *rand.Rand) {
+	type cell struct{ x, y int }
+	var deadEnds []cell
+	for y := 1; y < m.height-1; y += 2 {
+		for x := 1; x < m.width-1; x += 2 {
+			if m.exits(x, y) == 1 {
+				deadEnds = append(deadEnds, cell{x, y})
+			}
+		}
+	}
+	rng.Shuffle(len(deadEnds), func(i, j int) { deadEnds[i], deadEnds[j] = deadEnds[j], deadEnds[i] })
+	left := int(math.Round(fraction * float64(len(deadEnds))))
+	for _, c := range deadEnds {
+		if left <= 0 {
+			break
+		}
+		// it may be gone with one of the passages before
+		if m.exits(c.x, c.y) != 1 {
+			continue
+		}
+		// the walls towards the neighbours, split by the kind of neighbour
+		var toDeadEnds, toOthers []cell
+		for _, d := range directions {
+			nx, ny := c.x+2*d.x, c.y+2*d.y
+			if nx < 1 || ny < 1 || nx > m.width-2 || ny > m.height-2 || m.get(c.x+d.x, c.y+d.y) != WallCell {
+				continue
+			}
+			if m.exits(nx, ny) == 1 {
+				toDeadEnds = append(toDeadEnds, cell{c.x + d.x, c.y + d.y})
+			} else {
+				toOthers = append(toOthers, cell{c.x + d.x, c.y + d.y})
+			}
+		}
+		// two at once only if both have to go
+		if len(toDeadEnds) > 0 && left >= 2 {
+			w := toDeadEnds[rng.Intn(len(toDeadEnds))]
+			m.set(w.x, w.y, EmptyCell)
+			left -= 2
+		} else if len(toOthers) > 0 {
+			w := toOthers[rng.Intn(len(toOthers))]
+			m.set(w.x, w.y, EmptyCell)
+			left--
+		}
+	}
+}
+
+// the number of open sides of a cell leading to another cell, stairs included.
+// With an even size the last column or row is wider, not a way out
+func (m *Game) exits(x, y int) int {
+	n := 0
+	s := m.StairsAt(x, y)
+	for ; s != 0; s &= s - 1 {
+		n++
+	}
+	for _, d := range directions {
+		nx, ny := x+2*d.x, y+2*d.y
+		if nx < 1 || ny < 1 || nx > m.width-2 || ny > m.height-2 {
+			continue
+		}
+		if m.get(x+d.x, y+d.y) != WallCell {
+			n++
+		}
+	}
+	return n
+}
+
+// north, east, south and west
+var directions = [4]struct{ x, y int }{{0, -1}, {1, 0}, {0, 1}, {-1, 0}}
@@ -0,0 +1,148 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// how hard it is to walk from the player to the treasure, on the blocks:
+// a long way, with many turns to choose from and deep dead ends to get
+// lost in, makes a hard maze. Trap doors are walked like the floor,
+// and portals lead to their other end
+
+type difficulty struct {
+	length    int     // blocks on the shortest way to the treasure, -1 when there's none
+	decisions int     // junctions on the way, where the player must choose
+	deadEnds  float64 // average distance of the dead ends from the way
+	score     float64 // all of the above, comparable between mazes of any size
+}
+
+// the difficulty bands that can be asked for, from the easiest
+var Difficulties = []string{"easy", "medium", "hard"}
+
+// the lowest score of each band: with the default braid about a third
+// of the mazes of any size fall in each of them, fewer loops make
+// harder mazes
+var bandScores = []float64{0, 36, 44}
+
+// mazes generated looking for one in the band asked
+const difficultyTries = 200
+
+// the band of the score, see Difficulties
+func (d difficulty) band() string {
+	band := Difficulties[0]
+	for i, s := range bandScores {
+		if d.score >= s {
+			band = Difficulties[i]
+		}
+	}
+	return band
+}
+
+// how far the score is from the band, 0 when it's in it or when no band is asked
+func (d difficulty) offBand(band string) float64 {
+	i := slices.Index(Difficulties, band)
+	switch {
+	case band == "":
+		return 0
+	case d.length < 0:
+		return math.Inf(1)
+	case d.score < bandScores[i]:
+		return bandScores[i] - d.score
+	case i+1 < len(bandScores) && d.score >= bandScores[i+1]:
+		return d.score - bandScores[i+1] + 1
+	}
+	return 0
+}
+
+func (d difficulty) String() string {
+	if d.length < 0 {
+		return "no way to the treasure"
+	}
+	return fmt.Sprintf("%s %.1f", d.band(), d.score)
+}
+
+// like get, on any floor
+func (m *Game) getOn(z, x, y int) Cell {
+	current := m.floor
+	m.goToFloor(z)
+	c := m.get(x, y)
+	m.goToFloor(current)
+	return c
+}
+
+// the open blocks next to block i, stairs and portals included.
+// Blocks are numbered floor by floor, row by row
+func (m *Game) openAround(i int) []int {
+	around := m.stepsFrom(i)
+	if end := m.linkedEnd(i); end >= 0 {
+		around = append(around, end)
+	}
+	return around
+}
+
+// the open blocks next to block i and the ones the stairs lead to:
+// where the player can step, portals can only be left on foot
+func (m *Game) stepsFrom(i int) []int {
+	size := m.width * m.height
+	z, x, y := i/size, i%size%m.width, i%size/m.width
+	var around []int
+	for _, d := range directions {
+		if m.getOn(z, x+d.x, y+d.y) != WallCell {
+			around = append(around, i+d.y*m.width+d.x)
+		}
+	}
+	if m.stairs != nil {
+		if m.stairs[z][i%size]&StairsUp != 0 {
+			around = append(around, i+size)
+		}
+		if m.stairs[z][i%size]&StairsDown != 0 {
+			around = append(around, i-size)
+		}
+	}
+	return around
+}
+
+// breadth first from every block in from, the distance of each block
+// and the one it was reached from. Unreachable blocks are at -1
+func (m *Game) walk(from []int) ([]int, []int) {
+	return m.walkClosed(from, nil)
+}
+
+// the difficulty of the maze from where the player starts
+func (m *Game) difficulty() difficulty {
+	size := m.width * m.height
+	start := m.startZ*size + m.startY*m.width + m.startX
+	treasure := m.treasureZ*size + m.treasureY*m.width + m.treasureX
+	dist, prev := m.walk([]int{start})
+	d := difficulty{length: dist[treasure]}
+	if d.length < 0 {
+		return d
+	}
+	var way []int
+	for i := treasure; i >= 0; i = prev[i] {
+		way = append(way, i)
+		if i != treasure && i != start && len(m.openAround(i)) > 2 {
+			d.decisions++
+		}
+	}
+	// how far a wrong turn can take the player
+	fromWay, _ := m.walk(way)
+	deadEnds := 0
+	for i, n := range fromWay {
+		if n > 0 && len(m.openAround(i)) == 1 {
+			d.deadEnds += float64(n)
+			deadEnds++
+		}
+	}
+	if deadEnds > 0 {
+		d.deadEnds /= float64(deadEnds)
+	}
+	// every junction is worth a few steps, and all of it is compared
+	// to the side of the maze, so the bands fit mazes of any size:
+	// the way grows a little faster than the side, with its turns
+	side := math.Sqrt(float64(size * m.FloorCount()))
+	d.score = 20 * (float64(d.length) + 4*float64(d.decisions) + d.deadEnds) / math.Pow(side, 1.1)
+	return d
+}
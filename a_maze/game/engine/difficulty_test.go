@@ -0,0 +1,36 @@
+package engine
+
+import "testing"
+
+// about a third of the mazes of any size falls in each band
+func TestDifficultyBands(t *testing.T) {
+	const mazes = 90
+	for _, size := range [][2]int{{21, 11}, {41, 21}, {81, 41}} {
+		count := map[string]int{}
+		for seed := int64(1); seed <= mazes; seed++ {
+			m, err := newMaze(size[0], size[1], Options{Seed: seed, Braid: 0.2})
+			if err != nil {
+				t.Fatalf("%dx%d seed %d: %v", size[0], size[1], seed, err)
+			}
+			count[m.rating.band()]++
+		}
+		for _, band := range Difficulties {
+			if share := float64(count[band]) / mazes; share < 0.2 || share > 0.47 {
+				t.Errorf("%dx%d: %.0f%% of the mazes are %s, want about a third", size[0], size[1], 100*share, band)
+			}
+		}
+	}
+}
+
+// every band is found quickly in a big maze
+func TestDifficultyFound(t *testing.T) {
+	for _, band := range Difficulties {
+		g, err := NewGame(101, 51, Options{Seed: 1, Braid: 0.2, Difficulty: band})
+		if err != nil {
+			t.Fatalf("%s: %v", band, err)
+		}
+		if got := g.rating.band(); got != band {
+			t.Errorf("asked for a %s maze, got %s", band, got)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package engine
+
+import "strings"
+
+// the cells of the maze and the passages between them, like in
+// "Mazes for Programmers": no room wasted on the walls, every cell knows
+// its linked neighbours. Game is the block view of the same maze,
+// cells on odd coordinates and walls in between, the edges are read
+// from it to draw the map
+type edgeGrid struct {
+	width, height int     // in cells
+	links         []uint8 // the open sides of each cell, see side
+}
+
+// bit of each side in links, in the order of directions:
+// 1 north, 2 east, 4 south, 8 west
+func side(d int) uint8 {
+	return 1 << d
+}
+
+func newEdgeGrid(w, h int) edgeGrid {
+	return edgeGrid{width: w, height: h, links: make([]uint8, w*h)}
+}
+
+// opens the side d of the cell x,y and the opposite one of its neighbour
+func (e edgeGrid) link(x, y, d int) {
+	nx, ny := x+directions[d].x, y+directions[d].y
+	if x < 0 || y < 0 || nx < 0 || ny < 0 || x >= e.width || nx >= e.width || y >= e.height || ny >= e.height {
+		return
+	}
+	e.links[y*e.width+x] |= side(d)
+	e.links[ny*e.width+nx] |= side((d + 2) % 4)
+}
+
+// true if the cell x,y has a passage on the side d
+func (e edgeGrid) linked(x, y, d int) bool {
+	if x < 0 || y < 0 || x >= e.width || y >= e.height {
+		return false
+	}
+	return e.links[y*e.width+x]&side(d) != 0
+}
+
+// the cells and passages of the maze. Only the walls between two cells
+// count: with an even size the last column or row is just wider
+func (m *Game) edges() edgeGrid {
+	e := newEdgeGrid((m.width-1)/2, (m.height-1)/2)
+	for y := 0; y < e.height; y++ {
+		for x := 0; x < e.width; x++ {
+			px, py := 2*x+1, 2*y+1
+			if x < e.width-1 && m.get(px+1, py) != WallCell {
+				e.link(x, y, 1)
+			}
+			if y < e.height-1 && m.get(px, py+1) != WallCell {
+				e.link(x, y, 2)
+			}
+		}
+	}
+	return e
+}
+
+// true if there's a wall on the block between two cells, or on the border.
+// Cells and corners are never walls
+func (e edgeGrid) wall(x, y int) bool {
+	if x < 0 || y < 0 || x > 2*e.width || y > 2*e.height || x%2 == y%2 {
+		return false
+	}
+	if x%2 == 0 {
+		// between the cells on the west and on the east
+		return x == 0 || !e.linked(x/2-1, y/2, 1)
+	}
+	// between the cells on the north and on the south
+	return y == 0 || !e.linked(x/2, y/2-1, 2)
+}
+
+// indexed by the walls reaching a corner: 1 north, 2 east, 4 south, 8 west
+var cornerGlyphs = [16]rune{' ', '╵', '╶', '└', '╷', '│', '┌', '├', '╴', '┘', '─', '┴', '┐', '┤', '┬', '┼'}
+
+// the walls drawn with thin box lines, one char per block
+func (e edgeGrid) thinWalls() string {
+	var sb strings.Builder
+	for y := 0; y <= 2*e.height; y++ {
+		for x := 0; x <= 2*e.width; x++ {
+			switch {
+			case x%2 == 0 && y%2 == 0:
+				bits := 0
+				for i, d := range directions {
+					if e.wall(x+d.x, y+d.y) {
+						bits |= 1 << i
+					}
+				}
+				sb.WriteRune(cornerGlyphs[bits])
+			case !e.wall(x, y):
+				sb.WriteByte(' ')
+			case x%2 == 0:
+				sb.WriteRune('│')
+			default:
+				sb.WriteRune('─')
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// a map of the maze with thin walls
+func (m *Game) Map() string {
+	return m.edges().thinWalls()
+}
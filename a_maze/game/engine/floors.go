@@ -0,0 +1,65 @@
+package engine
+
+// a multi-level maze is a pile of floors of the same size, joined by
+// stairs: the stairs up on a floor and the stairs down on the one above
+// are in the same place. The player sees a floor at a time
+
+// about how many stairs go up from every floor
+const stairsPerFloor = 4
+
+// the bits returned by StairsAt
+const (
+	StairsUp = 1 << iota
+	StairsDown
+)
+
+// the number of floors, 1 for a flat maze
+func (m *Game) FloorCount() int {
+	return max(1, len(m.floors))
+}
+
+// the cells of floor z become the current ones
+func (m *Game) goToFloor(z int) {
+	if z >= 0 && z < len(m.floors) {
+		m.floor = z
+		m.cells = m.floors[z]
+	}
+}
+
+// like set, on any floor
+func (m *Game) setOn(z, x, y int, value Cell) {
+	current := m.floor
+	m.goToFloor(z)
+	m.set(x, y, value)
+	m.goToFloor(current)
+}
+
+// the stairs on the floor of the player
+func (m *Game) StairsAt(x, y int) byte {
+	if m.stairs == nil || x < 0 || y < 0 || x >= m.width || y >= m.height {
+		return 0
+	}
+	return m.stairs[m.floor][y*m.width+x]
+}
+
+// stairs from the current floor to the one above
+func (m *Game) addStairs(x, y int) {
+	i := y*m.width + x
+	m.stairs[m.floor][i] |= StairsUp
+	m.stairs[m.floor+1][i] |= StairsDown
+}
+
+// takes the stairs the player stands on, up when dz is 1 and down when -1
+func (m *Game) climb(dz int) []Event {
+	want := byte(StairsUp)
+	if dz < 0 {
+		want = StairsDown
+	}
+	if m.StairsAt(m.playerX, m.playerY)&want == 0 {
+		return []Event{Blocked}
+	}
+	m.leave()
+	m.goToFloor(m.floor + dz)
+	m.set(m.playerX, m.playerY, PlayerCell)
+	return m.arrive(Climbed)
+}
@@ -0,0 +1,153 @@
+package engine
+
+import (
+	"math/rand"
+	"slices"
+)
+
+// the player only sees the blocks in its line of sight, as far as its
+// light goes: walls and locked doors cast shadows. Blocks seen before
+// are remembered, torches make the light go farther.
+// Shadows are cast recursively, one octant at a time, like in
+// https://www.roguebasin.com/index.php/FOV_using_recursive_shadowcasting
+
+// the radius of the light in blocks when not given
+const DefaultLight = 6
+
+// torches in a generated maze at most, and how much farther each one lights
+const (
+	nTorches   = 2
+	torchLight = 3
+)
+
+// turns the coordinates of the first octant into the ones of the others
+var octants = [8][4]int{
+	{1, 0, 0, 1}, {0, 1, 1, 0}, {0, -1, 1, 0}, {-1, 0, 0, 1},
+	{-1, 0, 0, -1}, {0, -1, -1, 0}, {0, 1, -1, 0}, {1, 0, 0, -1},
+}
+
+// how far the player sees, torches included
+func (m *Game) LightRadius() int {
+	light := m.opts.Light
+	if light == 0 {
+		light = DefaultLight
+	}
+	for _, item := range m.inventory {
+		if item.Kind == TorchItem {
+			light += torchLight
+		}
+	}
+	return light
+}
+
+// true if the block x,y on the floor of the player is in its sight
+func (m *Game) Visible(x, y int) bool {
+	if x < 0 || y < 0 || x >= m.width || y >= m.height {
+		return false
+	}
+	return m.lit[y*m.width+x]
+}
+
+// true if the block x,y on the floor of the player has ever been in its sight
+func (m *Game) Seen(x, y int) bool {
+	if x < 0 || y < 0 || x >= m.width || y >= m.height {
+		return false
+	}
+	return m.seen[m.floor*m.width*m.height+y*m.width+x]
+}
+
+// true if the light doesn't go through block x,y
+func (m *Game) opaque(x, y int) bool {
+	c := m.get(x, y)
+	return c == WallCell || c == LockCell
+}
+
+// lights the blocks the player can see from where it is
+func (m *Game) look() {
+	size := m.width * m.height
+	if m.lit == nil {
+		m.lit = make([]bool, size)
+		m.seen = make([]bool, size*m.FloorCount())
+	}
+	clear(m.lit)
+	m.light(m.playerX, m.playerY)
+	for _, o := range octants {
+		m.castLight(1, 1, 0, o)
+	}
+}
+
+func (m *Game) light(x, y int) {
+	if x < 0 || y < 0 || x >= m.width || y >= m.height {
+		return
+	}
+	m.lit[y*m.width+x] = true
+	m.seen[m.floor*m.width*m.height+y*m.width+x] = true
+}
+
+// lights an octant from row on, between the slopes start and end,
+// and goes on recursively past the blocks casting a shadow
+func (m *Game) castLight(row int, start, end float64, o [4]int) {
+	if start < end {
+		return
+	}
+	radius := m.LightRadius()
+	for j := row; j <= radius; j++ {
+		blocked, nextStart := false, start
+		for dx := -j; dx <= 0; dx++ {
+			dy := -j
+			x, y := m.playerX+dx*o[0]+dy*o[1], m.playerY+dx*o[2]+dy*o[3]
+			left, right := (float64(dx)-0.5)/(float64(dy)+0.5), (float64(dx)+0.5)/(float64(dy)-0.5)
+			if start < right {
+				continue
+			}
+			if end > left {
+				break
+			}
+			if dx*dx+dy*dy <= radius*radius {
+				m.light(x, y)
+			}
+			switch {
+			case blocked && m.opaque(x, y):
+				nextStart = right
+			case blocked:
+				blocked, start = false, nextStart
+			case m.opaque(x, y) && j < radius:
+				blocked = true
+				m.castLight(j+1, start, left, o)
+				nextStart = right
+			}
+		}
+		if blocked {
+			return
+		}
+	}
+}
+
+// puts the torches where the player can walk to, with the keys it finds.
+// A small maze with no room for all of them gets fewer
+func (m *Game) placeTorches(rng *rand.Rand) {
+	dist, _ := m.walkWithKeys()
+	var blocks []int
+	for i, d := range dist {
+		if d > 0 && m.free(i) {
+			blocks = append(blocks, i)
+		}
+	}
+	size := m.width * m.height
+	for _, b := range rng.Perm(len(blocks))[:min(nTorches, len(blocks))] {
+		i := blocks[b]
+		m.torches = append(m.torches, i)
+		m.setOn(i/size, i%size%m.width, i%size/m.width, TorchCell)
+	}
+}
+
+// picks up the torch on the block of the player, if any
+func (m *Game) pickUpTorch() []Event {
+	n := slices.Index(m.torches, m.playerBlock())
+	if n < 0 {
+		return nil
+	}
+	m.torches[n] = -1
+	m.inventory = append(m.inventory, Item{Kind: TorchItem})
+	return []Event{PickedUp}
+}
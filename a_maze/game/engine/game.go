@@ -0,0 +1,342 @@
+package engine
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// the rules of the game, without a terminal: the player walks the maze
+// with Move, and every move tells what happened with a few events.
+// The game in a_maze/game/internal draws it and turns the keys into moves,
+// bots and servers can play it the same way
+
+// what is on a block of the maze:
+// 0 = empty space
+// 1 = wall
+// 2 = treasure
+// 3 = player
+// 4 = trap door
+// 5 = portal, see portals.go
+// 6 = key
+// 7 = locked door, see keys.go
+// 8 = torch, see fog.go
+type Cell byte
+
+const (
+	EmptyCell = iota
+	WallCell
+	TreasureCell
+	PlayerCell
+	DoorCell
+	PortalCell
+	KeyCell
+	LockCell
+	TorchCell
+	nDoors = 7 // trap doors at most, as many as a level can have
+)
+
+// the state of a game: the maze and where everything is
+type Game struct {
+	cells     []Cell
+	width     int
+	height    int
+	playerX   int
+	playerY   int
+	treasureX int
+	treasureY int
+	steps     int // moves done by the player, blocked ones excluded
+	doorsX    [nDoors]int
+	doorsY    [nDoors]int
+	startX    int // doors send the player back here
+	startY    int
+	startZ    int
+	// every floor of a multi-level maze, cells is the one of the player.
+	// A single floor maze has none, see floors.go
+	floors    [][]Cell
+	stairs    [][]byte // the stairs on each floor
+	floor     int      // the floor of the player
+	treasureZ int
+	doorsZ    [nDoors]int
+	opts      Options
+	level     string // file name for loaded levels, empty when generated
+	rating    difficulty
+	portals   []portal
+	locks     []int  // the block of each locked door, -1 once open
+	keys      []int  // the block of each key, -1 once picked up
+	inventory []Item // what the player carries
+	torches   []int  // the block of each torch, -1 once picked up
+	lit       []bool // the blocks the player sees on its floor
+	seen      []bool // the blocks seen on every floor, like in walk
+	won       bool
+}
+
+// settings that survive when the maze is regenerated
+type Options struct {
+	Seed  int64   // same seed and size always give the same maze
+	Braid float64 // fraction of the dead ends removed, 0 for a perfect maze
+	// size of the maze, 0 to fill the terminal. A bigger maze
+	// scrolls with the camera following the player
+	Width, Height int
+	Floors        int // floors connected by stairs, 0 or 1 for a flat maze
+	// one of Difficulties, the seed is increased until the maze is
+	// in this band. Empty for any maze
+	Difficulty string
+	// steps from the player to the treasure at least,
+	// 0 for a quarter of width and height
+	MinDistance int
+	Light       int // how far the player sees in blocks, 0 for DefaultLight
+}
+
+// generates mazes from the seed on until one is as difficult as asked.
+// Fails when none is in the band within difficultyTries mazes, or when
+// player, treasure and doors don't fit in the maze
+func NewGame(w, h int, opts Options) (*Game, error) {
+	best, err := newMaze(w, h, opts)
+	if err != nil {
+		return nil, err
+	}
+	for i := 1; i < difficultyTries && best.rating.offBand(opts.Difficulty) > 0; i++ {
+		opts.Seed++
+		m, err := newMaze(w, h, opts)
+		if err == nil && m.rating.offBand(opts.Difficulty) < best.rating.offBand(opts.Difficulty) {
+			best = m
+		}
+	}
+	if best.rating.offBand(opts.Difficulty) > 0 {
+		return nil, fmt.Errorf("no %s maze found in %d tries, the closest one is %s", opts.Difficulty, difficultyTries, best.rating)
+	}
+	return &best, nil
+}
+
+func newMaze(w, h int, opts Options) (Game, error) {
+	m := Game{width: w, height: h, opts: opts}
+	// never use the global source, or the maze can't be reproduced
+	rng := rand.New(rand.NewSource(opts.Seed))
+	floors := max(opts.Floors, 1)
+	if floors > 1 {
+		m.floors = make([][]Cell, floors)
+		m.stairs = make([][]byte, floors)
+	}
+	for z := 0; z < floors; z++ {
+		m.cells = make([]Cell, w*h)
+		if floors > 1 {
+			m.floors[z] = m.cells
+			m.stairs[z] = make([]byte, w*h)
+		}
+		// draw borders
+		for x := 0; x < w; x++ {
+			m.set(x, 0, 1)
+			m.set(x, h-1, 1)
+		}
+		for y := 0; y < h; y++ {
+			m.set(0, y, 1)
+			m.set(w-1, y, 1)
+		}
+		// draw grid on alternate cells
+		for x := 2; x < w-2; x += 2 {
+			for y := 1; y < h-1; y++ {
+				m.set(x, y, 1)
+			}
+		}
+		for y := 2; y < h-2; y += 2 {
+			for x := 1; x < w-1; x++ {
+				m.set(x, y, 1)
+			}
+		}
+	}
+	// starting on the upper row of the lowest floor, for each cell
+	// flip a coin in order to decide which direction to carve.
+	// The last row can only go east and the last column only south,
+	// so there are no closed pockets: the last cell of each floor
+	// takes the stairs, and once in a while another one does
+	upChance := max(1, (w/2)*(h/2)/stairsPerFloor)
+	for z := 0; z < floors; z++ {
+		m.goToFloor(z)
+		for y := 1; y < h-1; y += 2 {
+			for x := 1; x < w-1; x += 2 {
+				east, south, up := x+2 < w-1, y+2 < h-1, z < floors-1
+				if up && (!east && !south || rng.Intn(upChance) == 0) {
+					m.addStairs(x, y)
+				} else if east && (!south || rng.Intn(2) == 1) {
+					m.set(x+1, y, 0)
+				} else if south {
+					m.set(x, y+1, 0)
+				}
+			}
+		}
+		m.braid(opts.Braid, rng)
+	}
+	m.goToFloor(0)
+	// player (+/- in the center), treasure far enough and doors,
+	// all where the player can walk
+	if err := m.placePlayer(); err != nil {
+		return m, err
+	}
+	if err := m.placeTreasure(rng); err != nil {
+		return m, err
+	}
+	if err := m.placeDoors(rng); err != nil {
+		return m, err
+	}
+	if err := m.placePortals(rng); err != nil {
+		return m, err
+	}
+	m.placeLocks(rng)
+	m.placeTorches(rng)
+	m.look()
+	m.rating = m.difficulty()
+	return m, nil
+}
+
+func (m *Game) set(x, y int, value Cell) {
+	i := y*m.width + x
+	if i > len(m.cells)-1 || x < 0 || y < 0 || x >= m.width || y >= m.height {
+		return
+	}
+	m.cells[i] = value
+}
+
+func (m *Game) get(x, y int) Cell {
+	i := y*m.width + x
+	if i > len(m.cells)-1 || x < 0 || y < 0 || x >= m.width || y >= m.height {
+		return 1
+	}
+	return m.cells[i]
+}
+
+// what a move did, a move can have more than one
+type Event int
+
+const (
+	Moved      Event = iota // the player is on the next block
+	Blocked                 // a wall or no stairs, nothing changed
+	Climbed                 // the player took the stairs to another floor
+	Teleported              // a portal took the player to its other end
+	Trapped                 // a trap door sent the player back to the start
+	Locked                  // a locked door and no key, nothing changed
+	Unlocked                // the key opened a locked door
+	PickedUp                // the player took an item, see Inventory
+	Won                     // the player found the treasure, the game is over
+)
+
+var eventNames = [...]string{"moved", "blocked", "climbed", "teleported", "trapped", "locked", "unlocked", "picked up", "won"}
+
+func (e Event) String() string {
+	return eventNames[e]
+}
+
+// where the player can go: north, east, south and west like directions,
+// then up and down the stairs
+type Direction int
+
+const (
+	North Direction = iota
+	East
+	South
+	West
+	Up
+	Down
+)
+
+// moves the player a block in direction d, or a floor up or down
+// the stairs. After the game is won nothing moves anymore
+func (m *Game) Move(d Direction) []Event {
+	switch {
+	case m.won:
+		return []Event{Blocked}
+	case d == Up:
+		return m.climb(1)
+	case d == Down:
+		return m.climb(-1)
+	case d < North || d > Down:
+		return []Event{Blocked}
+	}
+	x, y := m.playerX+directions[d].x, m.playerY+directions[d].y
+	if m.get(x, y) == WallCell {
+		return []Event{Blocked}
+	}
+	var events []Event
+	if n := m.LockAt(x, y); n >= 0 {
+		if events = m.unlock(n); events[0] == Locked {
+			return events
+		}
+	}
+	m.leave()
+	m.playerX, m.playerY = x, y
+	m.set(m.playerX, m.playerY, PlayerCell)
+	return append(events, m.arrive(Moved)...)
+}
+
+// counts the step and checks what the player found where it ended
+func (m *Game) arrive(e Event) []Event {
+	events := []Event{e}
+	m.steps++
+	for i := 0; i < nDoors; i++ {
+		if m.playerX == m.doorsX[i] && m.playerY == m.doorsY[i] && m.floor == m.doorsZ[i] {
+			m.doorsX[i], m.doorsY[i] = 0, 0
+			m.jump(m.startZ*m.width*m.height + m.startY*m.width + m.startX)
+			events = append(events, Trapped)
+		}
+	}
+	if end := m.linkedEnd(m.playerBlock()); end >= 0 {
+		m.jump(end)
+		events = append(events, Teleported)
+	}
+	events = append(events, m.pickUp()...)
+	events = append(events, m.pickUpTorch()...)
+	if m.playerX == m.treasureX && m.playerY == m.treasureY && m.floor == m.treasureZ {
+		m.won = true
+		events = append(events, Won)
+	}
+	m.look()
+	return events
+}
+
+// the size of the maze in blocks
+func (m *Game) Width() int {
+	return m.width
+}
+
+func (m *Game) Height() int {
+	return m.height
+}
+
+// what is on a block of the floor of the player, walls outside of the maze
+func (m *Game) At(x, y int) Cell {
+	return m.get(x, y)
+}
+
+// where the player is
+func (m *Game) Player() (x, y int) {
+	return m.playerX, m.playerY
+}
+
+// the floor of the player, 0 is the lowest
+func (m *Game) Floor() int {
+	return m.floor
+}
+
+// moves done by the player, the blocked ones don't count
+func (m *Game) Steps() int {
+	return m.steps
+}
+
+// true once the player found the treasure
+func (m *Game) Won() bool {
+	return m.won
+}
+
+// the settings the maze was generated with
+func (m *Game) Options() Options {
+	return m.opts
+}
+
+// the file name of a loaded level, empty for a generated maze
+func (m *Game) Level() string {
+	return m.level
+}
+
+// how hard it is to reach the treasure, like "hard 31.2"
+func (m *Game) Difficulty() string {
+	return m.rating.String()
+}
@@ -0,0 +1,162 @@
+package engine
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// writes a level for the test, the format is in the name
+func writeLevel(t *testing.T, name, level string) string {
+	t.Helper()
+	name = filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(name, []byte(level), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+// loads a text level written by the test
+func loadLevel(t *testing.T, level string) *Game {
+	t.Helper()
+	g, err := LoadGame(writeLevel(t, "level.txt", level), Options{Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+// moves and checks the events of each move
+func play(t *testing.T, g *Game, moves []Direction, want [][]Event) {
+	t.Helper()
+	for i, d := range moves {
+		if got := g.Move(d); !slices.Equal(got, want[i]) {
+			t.Fatalf("move %d: got %v, want %v", i+1, got, want[i])
+		}
+	}
+}
+
+func checkPlayer(t *testing.T, g *Game, x, y int) {
+	t.Helper()
+	if px, py := g.Player(); px != x || py != y {
+		t.Fatalf("player at %d,%d, want %d,%d", px, py, x, y)
+	}
+}
+
+func TestWalls(t *testing.T) {
+	g := loadLevel(t, "#####\n#P T#\n#####\n")
+	play(t, g, []Direction{North, West, South}, [][]Event{{Blocked}, {Blocked}, {Blocked}})
+	checkPlayer(t, g, 1, 1)
+	if g.Steps() != 0 {
+		t.Errorf("%d steps, blocked moves don't count", g.Steps())
+	}
+	play(t, g, []Direction{East}, [][]Event{{Moved}})
+	checkPlayer(t, g, 2, 1)
+}
+
+func TestTreasure(t *testing.T) {
+	g := loadLevel(t, "#####\n#P T#\n#####\n")
+	play(t, g, []Direction{East, East, West}, [][]Event{{Moved}, {Moved, Won}, {Blocked}})
+	if !g.Won() || g.Steps() != 2 {
+		t.Errorf("won %v in %d steps, want true in 2", g.Won(), g.Steps())
+	}
+}
+
+func TestTrapDoor(t *testing.T) {
+	g := loadLevel(t, "######\n#P DT#\n######\n")
+	play(t, g, []Direction{East, East}, [][]Event{{Moved}, {Moved, Trapped}})
+	checkPlayer(t, g, 1, 1)
+	// the door is gone
+	play(t, g, []Direction{East, East, East}, [][]Event{{Moved}, {Moved}, {Moved, Won}})
+}
+
+func TestPortal(t *testing.T) {
+	g := loadLevel(t, "#######\n#P1#1T#\n#######\n")
+	play(t, g, []Direction{East}, [][]Event{{Moved, Teleported}})
+	checkPlayer(t, g, 4, 1)
+	if g.PortalAt(4, 1) != 0 || g.PortalAt(2, 1) != 0 {
+		t.Errorf("portal numbers %d and %d, want 0", g.PortalAt(2, 1), g.PortalAt(4, 1))
+	}
+	play(t, g, []Direction{East}, [][]Event{{Moved, Won}})
+}
+
+func TestPortalBack(t *testing.T) {
+	g := loadLevel(t, "########\n#P1#1 T#\n########\n")
+	// stepping off the portal and back on takes the player back
+	play(t, g, []Direction{East, East, West}, [][]Event{{Moved, Teleported}, {Moved}, {Moved, Teleported}})
+	checkPlayer(t, g, 2, 1)
+	if g.At(4, 1) != PortalCell {
+		t.Errorf("the portal left behind is %d, want %d", g.At(4, 1), PortalCell)
+	}
+}
+
+func TestKeysAndLocks(t *testing.T) {
+	g := loadLevel(t, "######\n#aPAT#\n######\n")
+	play(t, g, []Direction{East}, [][]Event{{Locked}})
+	checkPlayer(t, g, 2, 1)
+	play(t, g, []Direction{West}, [][]Event{{Moved, PickedUp}})
+	if got := g.Inventory(); !slices.Equal(got, []Item{{KeyItem, 0}}) {
+		t.Fatalf("inventory %v, want the key of door A", got)
+	}
+	play(t, g, []Direction{East, East}, [][]Event{{Moved}, {Unlocked, Moved}})
+	if g.LockAt(3, 1) >= 0 {
+		t.Error("the door is still locked")
+	}
+	play(t, g, []Direction{East}, [][]Event{{Moved, Won}})
+}
+
+func TestTorch(t *testing.T) {
+	g := loadLevel(t, "#####\n#Pt #\n#  T#\n#####\n")
+	light := g.LightRadius()
+	play(t, g, []Direction{East}, [][]Event{{Moved, PickedUp}})
+	if g.LightRadius() != light+torchLight {
+		t.Errorf("light radius %d, want %d", g.LightRadius(), light+torchLight)
+	}
+}
+
+func TestStairs(t *testing.T) {
+	g, err := NewGame(21, 11, Options{Seed: 1, Floors: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := g.width * g.height
+	play(t, g, []Direction{Down}, [][]Event{{Blocked}})
+	// put the player on stairs leading to a free block
+	for i := range size {
+		x, y := i%g.width, i/g.width
+		if g.stairs[0][i]&StairsUp != 0 && g.getOn(0, x, y) == EmptyCell && g.getOn(1, x, y) == EmptyCell {
+			g.jump(i)
+			play(t, g, []Direction{Up}, [][]Event{{Climbed}})
+			if g.Floor() != 1 {
+				t.Fatalf("on floor %d after climbing, want 1", g.Floor())
+			}
+			checkPlayer(t, g, x, y)
+			play(t, g, []Direction{Up, Down}, [][]Event{{Blocked}, {Climbed}})
+			if g.Floor() != 0 {
+				t.Fatalf("on floor %d after going down, want 0", g.Floor())
+			}
+			return
+		}
+	}
+	t.Fatal("no free stairs")
+}
+
+// a player moving at random finds the treasure in every maze, whatever
+// the portals, doors and keys on the way
+func TestRandomWalk(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		g, err := NewGame(21, 11, Options{Seed: seed, Braid: 0.3, Floors: 2})
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		rng := rand.New(rand.NewSource(seed))
+		for i := 0; i < 1_000_000 && !g.Won(); i++ {
+			g.Move(Direction(rng.Intn(6)))
+		}
+		if !g.Won() {
+			t.Errorf("seed %d: no treasure after a million moves", seed)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package engine
+
+import (
+	"math/rand"
+	"slices"
+)
+
+// locked doors block the way until the player holds the key with their
+// number, then they open for good. Keys are placed after their door,
+// somewhere the player can walk to with the keys found before, so the
+// maze can always be solved: the first door needs no key to reach its key,
+// the second one at most the first key and so on
+
+// locked doors in a generated maze at most, each with its key
+const nLocks = 3
+
+// what the player can carry
+type ItemKind int
+
+const (
+	KeyItem ItemKind = iota
+	TorchItem
+)
+
+// something the player carries
+type Item struct {
+	Kind   ItemKind
+	Number int // of a key, the same of its door
+}
+
+// the items carried by the player, in the order they were picked up
+func (m *Game) Inventory() []Item {
+	return slices.Clone(m.inventory)
+}
+
+// the number of the key at x,y on the floor of the player, -1 for none
+func (m *Game) KeyAt(x, y int) int {
+	return m.numberAt(m.keys, x, y)
+}
+
+// the number of the locked door at x,y on the floor of the player, -1 for none
+func (m *Game) LockAt(x, y int) int {
+	return m.numberAt(m.locks, x, y)
+}
+
+// the index in blocks of the block x,y on the floor of the player
+func (m *Game) numberAt(blocks []int, x, y int) int {
+	if x < 0 || y < 0 || x >= m.width || y >= m.height {
+		return -1
+	}
+	return slices.Index(blocks, m.floor*m.width*m.height+y*m.width+x)
+}
+
+// true if the player carries the key of door n
+func (m *Game) holds(n int) bool {
+	return slices.Contains(m.inventory, Item{KeyItem, n})
+}
+
+// opens the door n if the player holds its key
+func (m *Game) unlock(n int) []Event {
+	if !m.holds(n) {
+		return []Event{Locked}
+	}
+	size := m.width * m.height
+	i := m.locks[n]
+	m.setOn(i/size, i%size%m.width, i%size/m.width, EmptyCell)
+	m.locks[n] = -1
+	return []Event{Unlocked}
+}
+
+// picks up the key on the block of the player, if any
+func (m *Game) pickUp() []Event {
+	n := slices.Index(m.keys, m.playerBlock())
+	if n < 0 {
+		return nil
+	}
+	m.keys[n] = -1
+	m.inventory = append(m.inventory, Item{KeyItem, n})
+	return []Event{PickedUp}
+}
+
+// like walk, but the blocks in closed can't be entered
+func (m *Game) walkClosed(from []int, closed map[int]bool) ([]int, []int) {
+	n := m.width * m.height * m.FloorCount()
+	dist, prev := make([]int, n), make([]int, n)
+	for i := range dist {
+		dist[i], prev[i] = -1, -1
+	}
+	for _, i := range from {
+		dist[i] = 0
+	}
+	queue := slices.Clone(from)
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		for _, n := range m.openAround(i) {
+			if dist[n] < 0 && !closed[n] {
+				dist[n], prev[n] = dist[i]+1, i
+				queue = append(queue, n)
+			}
+		}
+	}
+	return dist, prev
+}
+
+// walks from the start like the player would: a locked door opens
+// once its key can be reached. Returns distances and previous blocks
+// like walk, with all the doors that can be opened open
+func (m *Game) walkWithKeys() ([]int, []int) {
+	start := m.startZ*m.width*m.height + m.startY*m.width + m.startX
+	open := map[int]bool{}
+	for {
+		closed := map[int]bool{}
+		for n, i := range m.locks {
+			if i >= 0 && !open[n] && !m.holds(n) {
+				closed[i] = true
+			}
+		}
+		dist, prev := m.walkClosed([]int{start}, closed)
+		opened := false
+		for n, i := range m.keys {
+			if i >= 0 && dist[i] >= 0 && !open[n] {
+				open[n], opened = true, true
+			}
+		}
+		if !opened {
+			return dist, prev
+		}
+	}
+}
+
+// true if the player can always get to the treasure, wherever it walks
+// with the keys it finds. A step is undone by stepping back, but the end
+// of a portal can only be left on foot: one boxed in by locked doors, or
+// a pocket behind them, traps a player without their keys for good.
+// Trap doors count as floor, they would only send the player back
+func (m *Game) escapable() bool {
+	size := m.width * m.height
+	n := size * m.FloorCount()
+	treasure := m.treasureZ*size + m.treasureY*m.width + m.treasureX
+	held := 0
+	for _, item := range m.inventory {
+		if item.Kind == KeyItem {
+			held |= 1 << item.Number
+		}
+	}
+	// a state is a block and the keys held, one bit each: n*held + block
+	start := held*n + m.startZ*size + m.startY*m.width + m.startX
+	reached := make([]bool, n<<len(m.locks))
+	back := map[int][]int{}
+	reached[start] = true
+	queue := []int{start}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		i, held := s%n, s/n
+		if i == treasure {
+			continue
+		}
+		for _, b := range m.stepsFrom(i) {
+			if k := slices.Index(m.locks, b); k >= 0 && held&(1<<k) == 0 {
+				continue
+			}
+			if end := m.linkedEnd(b); end >= 0 {
+				b = end
+			}
+			h := held
+			if k := slices.Index(m.keys, b); k >= 0 {
+				h |= 1 << k
+			}
+			t := h*n + b
+			back[t] = append(back[t], s)
+			if !reached[t] {
+				reached[t] = true
+				queue = append(queue, t)
+			}
+		}
+	}
+	// back from the treasure, every state reached must lead to it
+	won := make([]bool, len(reached))
+	for h := 0; h < 1<<len(m.locks); h++ {
+		if s := h*n + treasure; reached[s] {
+			won[s] = true
+			queue = append(queue, s)
+		}
+	}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		for _, p := range back[s] {
+			if !won[p] {
+				won[p] = true
+				queue = append(queue, p)
+			}
+		}
+	}
+	for s, r := range reached {
+		if r && !won[s] {
+			return false
+		}
+	}
+	return true
+}
+
+// true if a locked door or a key can go on block i:
+// an empty one, without stairs
+func (m *Game) free(i int) bool {
+	size := m.width * m.height
+	z, x, y := i/size, i%size%m.width, i%size/m.width
+	return m.getOn(z, x, y) == EmptyCell && (m.stairs == nil || m.stairs[z][i%size] == 0)
+}
+
+// puts the locked doors on the way to the treasure, where they cut it
+// off when possible, and each key where it can be reached before its door.
+// A door that could trap the player is never placed, and when there's
+// no room left a small maze gets fewer doors
+func (m *Game) placeLocks(rng *rand.Rand) {
+	size := m.width * m.height
+	treasure := m.treasureZ*size + m.treasureY*m.width + m.treasureX
+	for n := range nLocks {
+		dist, prev := m.walkWithKeys()
+		onWay := map[int]bool{}
+		for i := prev[treasure]; i >= 0; i = prev[i] {
+			onWay[i] = true
+		}
+		// the corridors where a door fits, the ones on the way
+		// to the treasure first: only they can cut it off
+		var way, others []int
+		for i, d := range dist {
+			switch {
+			case d <= 0 || !m.free(i) || len(m.openAround(i)) != 2:
+			case onWay[i]:
+				way = append(way, i)
+			default:
+				others = append(others, i)
+			}
+		}
+		rng.Shuffle(len(way), func(a, b int) { way[a], way[b] = way[b], way[a] })
+		rng.Shuffle(len(others), func(a, b int) { others[a], others[b] = others[b], others[a] })
+		door, key := -1, -1
+		for _, i := range append(way, others...) {
+			if door >= 0 && !onWay[i] {
+				break
+			}
+			m.locks = append(m.locks, i)
+			m.keys = append(m.keys, -1)
+			dist, _ := m.walkWithKeys()
+			m.locks, m.keys = m.locks[:n], m.keys[:n]
+			var before []int
+			for b, d := range dist {
+				if d > 0 && b != i && m.free(b) {
+					before = append(before, b)
+				}
+			}
+			// a door the player can walk around is kept only if there's no other
+			if len(before) == 0 || door >= 0 && dist[treasure] >= 0 {
+				continue
+			}
+			k := before[rng.Intn(len(before))]
+			m.locks, m.keys = append(m.locks, i), append(m.keys, k)
+			trapped := !m.escapable()
+			m.locks, m.keys = m.locks[:n], m.keys[:n]
+			if trapped {
+				continue
+			}
+			door, key = i, k
+			if dist[treasure] < 0 || !onWay[i] {
+				break
+			}
+		}
+		if door < 0 {
+			return
+		}
+		m.locks, m.keys = append(m.locks, door), append(m.keys, key)
+		m.setOn(door/size, door%size%m.width, door%size/m.width, LockCell)
+		m.setOn(key/size, key%size%m.width, key%size/m.width, KeyCell)
+	}
+}
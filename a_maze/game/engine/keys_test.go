@@ -0,0 +1,24 @@
+package engine
+
+import "testing"
+
+// the locked doors never box in a portal end or a pocket
+func TestLocksNeverTrap(t *testing.T) {
+	for seed := int64(1); seed <= 300; seed++ {
+		g, err := NewGame(12, 9, Options{Seed: seed, Braid: 0.75})
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		if !g.escapable() {
+			t.Errorf("seed %d: the player can get trapped", seed)
+		}
+	}
+}
+
+func TestLevelTrap(t *testing.T) {
+	// the other end of the portal is between two locked doors
+	level := "#######\n#P1abT#\n#######\n#A1B  #\n#######\n"
+	if _, err := LoadGame(writeLevel(t, "trap.txt", level), Options{}); err == nil {
+		t.Error("expected an error for a level where the player gets trapped")
+	}
+}
@@ -0,0 +1,290 @@
+package engine
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"image"
+	_ "image/png"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// hand-designed levels can be loaded from:
+// .txt  = '#' wall, ' ' floor, 'P' player, 'T' treasure, 'D' trap door,
+//         '1' to '9' portals, each digit on both of its ends,
+//         'a' to 'c' keys and 'A' to 'C' their locked doors, 't' torch
+// .json = width, height and cells, with the Cell values: portals, keys
+//         and locked doors go in pairs by number, only .txt levels have them
+// .png  = dark pixels are walls, light pixels are floor
+// The mazes exported by the generator can be loaded as they are.
+// A missing player is placed at random, a missing treasure far enough from it
+
+// smallest maze that makes sense: a border around a single cell
+const minSize = 3
+
+var levelChars = map[byte]Cell{
+	'#': WallCell,
+	' ': EmptyCell,
+	'T': TreasureCell,
+	'P': PlayerCell,
+	'D': DoorCell,
+	't': TorchCell,
+}
+
+type levelJSON struct {
+	Width  int   `json:"width"`
+	Height int   `json:"height"`
+	Cells  []int `json:"cells"`
+}
+
+// loads a level, the format is guessed from the file extension
+func LoadGame(name string, opts Options) (*Game, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var m Game
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".txt":
+		m, err = readText(f)
+	case ".json":
+		m, err = readJSON(f)
+	case ".png":
+		m, err = readImage(f)
+	default:
+		err = fmt.Errorf("unknown level format, use .txt, .json or .png")
+	}
+	if err == nil {
+		m.opts = opts
+		m.level = filepath.Base(name)
+		err = m.placeItems(rand.New(rand.NewSource(opts.Seed)))
+		m.rating = m.difficulty()
+		m.look()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return &m, nil
+}
+
+func newEmptyMaze(w, h int) (Game, error) {
+	if w < minSize || h < minSize {
+		return Game{}, fmt.Errorf("maze is %dx%d, must be at least %dx%d", w, h, minSize, minSize)
+	}
+	return Game{cells: make([]Cell, w*h), width: w, height: h}, nil
+}
+
+func readText(r io.Reader) (Game, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimRight(scanner.Text(), "\r"))
+	}
+	if err := scanner.Err(); err != nil {
+		return Game{}, err
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return Game{}, fmt.Errorf("empty level")
+	}
+	m, err := newEmptyMaze(len(lines[0]), len(lines))
+	if err != nil {
+		return Game{}, err
+	}
+	ends := map[byte][]int{}
+	var keys, locks [nLocks][]int
+	for y, line := range lines {
+		if len(line) != m.width {
+			return Game{}, fmt.Errorf("line %d is %d chars long, expected %d", y+1, len(line), m.width)
+		}
+		for x, ch := range []byte(line) {
+			if ch >= '1' && ch <= '9' {
+				ends[ch] = append(ends[ch], y*m.width+x)
+				m.set(x, y, PortalCell)
+				continue
+			}
+			if n := strings.IndexByte("abc", ch); n >= 0 {
+				keys[n] = append(keys[n], y*m.width+x)
+				m.set(x, y, KeyCell)
+				continue
+			}
+			if n := strings.IndexByte("ABC", ch); n >= 0 {
+				locks[n] = append(locks[n], y*m.width+x)
+				m.set(x, y, LockCell)
+				continue
+			}
+			c, ok := levelChars[ch]
+			if !ok {
+				return Game{}, fmt.Errorf("line %d, column %d: unexpected %q, allowed chars are '#', ' ', 'P', 'T', 'D', '1' to '9', 'a' to 'c', 'A' to 'C' and 't'", y+1, x+1, ch)
+			}
+			m.set(x, y, c)
+		}
+	}
+	for ch := byte('1'); ch <= '9'; ch++ {
+		switch len(ends[ch]) {
+		case 0:
+		case 2:
+			m.portals = append(m.portals, portal{ends[ch][0], ends[ch][1]})
+		default:
+			return Game{}, fmt.Errorf("portal %c needs 2 ends, found %d", ch, len(ends[ch]))
+		}
+	}
+	for n := range nLocks {
+		if len(keys[n]) != len(locks[n]) || len(keys[n]) > 1 {
+			return Game{}, fmt.Errorf("key %c and door %c go together, one of each, found %d and %d", 'a'+n, 'A'+n, len(keys[n]), len(locks[n]))
+		}
+		if len(keys[n]) == 1 {
+			m.keys, m.locks = append(m.keys, keys[n][0]), append(m.locks, locks[n][0])
+		} else {
+			// keep the numbers of the others
+			m.keys, m.locks = append(m.keys, -1), append(m.locks, -1)
+		}
+	}
+	return m, nil
+}
+
+func readJSON(r io.Reader) (Game, error) {
+	var j levelJSON
+	if err := json.NewDecoder(r).Decode(&j); err != nil {
+		return Game{}, err
+	}
+	m, err := newEmptyMaze(j.Width, j.Height)
+	if err != nil {
+		return Game{}, err
+	}
+	if len(j.Cells) != j.Width*j.Height {
+		return Game{}, fmt.Errorf("%d cells for a %dx%d maze, expected %d", len(j.Cells), j.Width, j.Height, j.Width*j.Height)
+	}
+	for i, c := range j.Cells {
+		switch {
+		case c == PortalCell || c == KeyCell || c == LockCell:
+			return Game{}, fmt.Errorf("cell %d is a portal, a key or a locked door, they have a number and only text levels can have them", i)
+		case c < EmptyCell || c > TorchCell:
+			return Game{}, fmt.Errorf("cell %d has invalid value %d", i, c)
+		}
+		m.cells[i] = Cell(c)
+	}
+	return m, nil
+}
+
+// images exported by the generator are scaled up,
+// every square of equal pixels becomes a single cell
+func readImage(r io.Reader) (Game, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return Game{}, err
+	}
+	b := img.Bounds()
+	scale := gcd(b.Dx(), b.Dy())
+	dark := func(x, y int) bool {
+		r, g, bl, a := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+		return a >= 0x8000 && 299*r+587*g+114*bl < 500*0xffff
+	}
+	for y := 0; y < b.Dy() && scale > 1; y++ {
+		run := 1
+		for x := 1; x < b.Dx(); x++ {
+			if dark(x, y) == dark(x-1, y) {
+				run++
+				continue
+			}
+			scale = gcd(scale, run)
+			run = 1
+		}
+	}
+	for x := 0; x < b.Dx() && scale > 1; x++ {
+		run := 1
+		for y := 1; y < b.Dy(); y++ {
+			if dark(x, y) == dark(x, y-1) {
+				run++
+				continue
+			}
+			scale = gcd(scale, run)
+			run = 1
+		}
+	}
+	m, err := newEmptyMaze(b.Dx()/scale, b.Dy()/scale)
+	if err != nil {
+		return Game{}, err
+	}
+	for y := 0; y < m.height; y++ {
+		for x := 0; x < m.width; x++ {
+			if dark(x*scale, y*scale) {
+				m.set(x, y, WallCell)
+			}
+		}
+	}
+	return m, nil
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// finds player, treasure and doors of a loaded level. The missing player
+// goes on a random floor cell and the missing treasure far enough from it,
+// see placement.go
+func (m *Game) placeItems(rng *rand.Rand) error {
+	var players, treasures, doors, floor int
+	for y := 0; y < m.height; y++ {
+		for x := 0; x < m.width; x++ {
+			switch m.get(x, y) {
+			case PlayerCell:
+				m.playerX, m.playerY = x, y
+				players++
+			case TreasureCell:
+				m.treasureX, m.treasureY = x, y
+				treasures++
+			case DoorCell:
+				if doors == nDoors {
+					return fmt.Errorf("too many doors, at most %d are allowed", nDoors)
+				}
+				m.doorsX[doors], m.doorsY[doors] = x, y
+				doors++
+			case TorchCell:
+				m.torches = append(m.torches, y*m.width+x)
+			case EmptyCell:
+				floor++
+			}
+		}
+	}
+	if players > 1 || treasures > 1 {
+		return fmt.Errorf("found %d players and %d treasures, at most one of each is allowed", players, treasures)
+	}
+	if floor < 1-players {
+		return fmt.Errorf("not enough floor to place the player")
+	}
+	if players == 0 {
+		m.playerX, m.playerY = m.randomFloor(rng)
+		m.set(m.playerX, m.playerY, PlayerCell)
+	}
+	m.startX, m.startY = m.playerX, m.playerY
+	if treasures == 0 {
+		if err := m.placeTreasure(rng); err != nil {
+			return err
+		}
+	}
+	if !m.escapable() {
+		return fmt.Errorf("the treasure can't be reached from the player, a key is behind its own door or the player can get trapped")
+	}
+	return nil
+}
+
+// there must be at least one empty cell
+func (m *Game) randomFloor(rng *rand.Rand) (int, int) {
+	for {
+		x, y := rng.Intn(m.width), rng.Intn(m.height)
+		if m.get(x, y) == EmptyCell {
+			return x, y
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// player, treasure and doors only go on blocks the player can walk to:
+// the player starts near the center of the lowest floor, the treasure is
+// at least a minimum number of steps away and the doors are anywhere else.
+// Walls and sealed pockets are never picked
+
+// the minimum steps from the player to the treasure when not given:
+// a quarter of the way around the maze
+func defaultDistance(w, h int) int {
+	return (w + h) / 4
+}
+
+// trap doors in a generated maze, the portals are the other doors
+const trapDoors = 4
+
+// the open block of floor z nearest to x,y, -1 when there's none
+func (m *Game) nearestOpen(z, x, y int) int {
+	best, nearest := math.MaxInt, -1
+	for by := 0; by < m.height; by++ {
+		for bx := 0; bx < m.width; bx++ {
+			if d := abs(bx-x) + abs(by-y); m.getOn(z, bx, by) == EmptyCell && d < best {
+				best, nearest = d, by*m.width+bx
+			}
+		}
+	}
+	return nearest
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// the empty blocks the player can walk to, with their distance
+func (m *Game) reachable() ([]int, []int) {
+	size := m.width * m.height
+	dist, _ := m.walk([]int{m.startZ*size + m.startY*m.width + m.startX})
+	var blocks []int
+	for i, d := range dist {
+		if d > 0 && m.getOn(i/size, i%size%m.width, i%size/m.width) == EmptyCell {
+			blocks = append(blocks, i)
+		}
+	}
+	return blocks, dist
+}
+
+// puts the player on the open block nearest to the center of the lowest floor
+func (m *Game) placePlayer() error {
+	start := m.nearestOpen(0, m.width/2, m.height/2)
+	if start < 0 {
+		return fmt.Errorf("no floor for the player")
+	}
+	m.goToFloor(0)
+	m.playerX, m.playerY = start%m.width, start/m.width
+	m.startX, m.startY, m.startZ = m.playerX, m.playerY, 0
+	m.set(m.playerX, m.playerY, PlayerCell)
+	return nil
+}
+
+// puts the treasure on a random block at least the minimum
+// distance from the player, on any floor
+func (m *Game) placeTreasure(rng *rand.Rand) error {
+	minDistance := m.opts.MinDistance
+	if minDistance == 0 {
+		minDistance = defaultDistance(m.width, m.height)
+	}
+	blocks, dist := m.reachable()
+	var far []int
+	farthest := 0
+	for _, i := range blocks {
+		farthest = max(farthest, dist[i])
+		if dist[i] >= minDistance {
+			far = append(far, i)
+		}
+	}
+	if len(far) == 0 {
+		return fmt.Errorf("the treasure must be at least %d steps from the player, but the farthest floor is %d steps away", minDistance, farthest)
+	}
+	i := far[rng.Intn(len(far))]
+	size := m.width * m.height
+	m.treasureZ, m.treasureX, m.treasureY = i/size, i%size%m.width, i%size/m.width
+	m.setOn(m.treasureZ, m.treasureX, m.treasureY, TreasureCell)
+	return nil
+}
+
+// drops the doors on random blocks the player can reach
+func (m *Game) placeDoors(rng *rand.Rand) error {
+	blocks, _ := m.reachable()
+	if len(blocks) < trapDoors {
+		return fmt.Errorf("only %d free blocks can be reached for %d doors", len(blocks), trapDoors)
+	}
+	size := m.width * m.height
+	for d, b := range rng.Perm(len(blocks))[:trapDoors] {
+		i := blocks[b]
+		m.doorsZ[d], m.doorsX[d], m.doorsY[d] = i/size, i%size%m.width, i%size/m.width
+		m.setOn(m.doorsZ[d], m.doorsX[d], m.doorsY[d], DoorCell)
+	}
+	return nil
+}
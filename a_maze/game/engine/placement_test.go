@@ -0,0 +1,45 @@
+package engine
+
+import "testing"
+
+// the smallest maze the game accepts always has room for everything,
+// with fewer locked doors and torches when needed
+func TestSmallMazes(t *testing.T) {
+	for _, floors := range []int{1, 2} {
+		for seed := int64(1); seed <= 100; seed++ {
+			_, err := NewGame(7, 7, Options{Seed: seed, Braid: 0.2, Floors: floors})
+			if err != nil {
+				t.Fatalf("%d floors, seed %d: %v", floors, seed, err)
+			}
+		}
+	}
+}
+
+func TestTreasureTooFar(t *testing.T) {
+	if _, err := NewGame(21, 11, Options{Seed: 1, MinDistance: 1000}); err == nil {
+		t.Error("expected an error for a treasure farther than the maze")
+	}
+}
+
+func TestLevelErrors(t *testing.T) {
+	for _, c := range []struct {
+		name, level string
+	}{
+		{"two players", "#####\n#PP #\n#  T#\n#####\n"},
+		{"treasure walled in", "######\n#P#T #\n######\n"},
+		{"no floor for the treasure", "###\n#P#\n###\n"},
+		{"one portal end", "#####\n#P1T#\n#####\n"},
+		{"key without door", "#####\n#PaT#\n#####\n"},
+		{"key behind its door", "######\n#PAaT#\n######\n"},
+		{"too many doors", "###########\n#PDDDDDDDD#\n#        T#\n###########\n"},
+	} {
+		if _, err := LoadGame(writeLevel(t, "level.txt", c.level), Options{Seed: 1}); err == nil {
+			t.Errorf("%s: expected an error", c.name)
+		}
+	}
+	// portals, keys and locked doors need a number, JSON has none
+	level := `{"width":4,"height":3,"cells":[1,1,1,1, 1,3,6,1, 1,1,1,1]}`
+	if _, err := LoadGame(writeLevel(t, "level.json", level), Options{Seed: 1}); err == nil {
+		t.Error("key in JSON: expected an error")
+	}
+}
@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// doors come in two kinds: a trap door sends the player back to the start
+// and disappears, a portal is linked to another one far away and takes the
+// player there and back as many times as needed. The two ends of a portal
+// are told apart from the other portals by its number
+
+// portals placed in a generated maze, each with two ends
+const nPortals = 3
+
+// the ends of a portal, as blocks numbered like in walk
+type portal [2]int
+
+// the number of the portal at x,y on the floor of the player, -1 for none
+func (m *Game) PortalAt(x, y int) int {
+	if x < 0 || y < 0 || x >= m.width || y >= m.height {
+		return -1
+	}
+	i := m.floor*m.width*m.height + y*m.width + x
+	for n, p := range m.portals {
+		if p[0] == i || p[1] == i {
+			return n
+		}
+	}
+	return -1
+}
+
+// the other end of the portal on block i, -1 when there's none
+func (m *Game) linkedEnd(i int) int {
+	for _, p := range m.portals {
+		switch i {
+		case p[0]:
+			return p[1]
+		case p[1]:
+			return p[0]
+		}
+	}
+	return -1
+}
+
+// the block of the player, numbered like in walk
+func (m *Game) playerBlock() int {
+	return m.floor*m.width*m.height + m.playerY*m.width + m.playerX
+}
+
+// the player steps off its block, portals stay where they are
+func (m *Game) leave() {
+	if m.linkedEnd(m.playerBlock()) >= 0 {
+		m.set(m.playerX, m.playerY, PortalCell)
+		return
+	}
+	m.set(m.playerX, m.playerY, EmptyCell)
+}
+
+// moves the player to block i, on any floor
+func (m *Game) jump(i int) {
+	size := m.width * m.height
+	m.leave()
+	m.goToFloor(i / size)
+	m.playerX, m.playerY = i%size%m.width, i%size/m.width
+	m.set(m.playerX, m.playerY, PlayerCell)
+}
+
+// puts the portals on blocks the player can reach, the second end of each
+// one at least as far from the first as the treasure from the player
+func (m *Game) placePortals(rng *rand.Rand) error {
+	minDistance := m.opts.MinDistance
+	if minDistance == 0 {
+		minDistance = defaultDistance(m.width, m.height)
+	}
+	size := m.width * m.height
+	for range nPortals {
+		blocks, _ := m.reachable()
+		if len(blocks) < 2 {
+			return fmt.Errorf("no free blocks can be reached for %d portals", nPortals)
+		}
+		a := blocks[rng.Intn(len(blocks))]
+		dist, _ := m.walk([]int{a})
+		var far []int
+		for _, b := range blocks {
+			if dist[b] >= minDistance {
+				far = append(far, b)
+			}
+		}
+		if len(far) == 0 {
+			return fmt.Errorf("the ends of a portal must be at least %d steps apart, there's no room for them", minDistance)
+		}
+		b := far[rng.Intn(len(far))]
+		m.portals = append(m.portals, portal{a, b})
+		m.setOn(a/size, a%size%m.width, a%size/m.width, PortalCell)
+		m.setOn(b/size, b%size%m.width, b%size/m.width, PortalCell)
+	}
+	return nil
+}
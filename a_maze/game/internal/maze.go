@@ -1,11 +1,13 @@
 package maze
 
 import (
-	"math/rand"
+	"fmt"
+	"slices"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/ilmanzo/hackweek24/a_maze/game/engine"
 )
 
 // use official SUSE colors for default background and foreground
@@ -16,22 +18,10 @@ var mazeStyle = lipgloss.NewStyle().Background(commonBG).Foreground(lipgloss.Col
 var playerStyle = lipgloss.NewStyle().Background(commonBG).Foreground(lipgloss.Color("#efefef"))
 var treasureStyle = lipgloss.NewStyle().Background(commonBG).Foreground(lipgloss.Color("#fe7c3f"))
 
-type cellContent byte
+// stairs in SUSE Mint, to stand out from the walls
+var stairsStyle = lipgloss.NewStyle().Background(commonBG).Foreground(lipgloss.Color("#90ebcd"))
 
-const (
-	EmptyCell = iota
-	WallCell
-	TreasureCell
-	PlayerCell
-	DoorCell
-	nDoors = 7
-)
-
-// 0 = empty space
-// 1 = wall
-// 2 = treasure
-// 3 = player
-// 4 = door
+// indexed by the engine.Cell values, see there
 var valToString = [5]string{
 	mazeStyle.Render("  "),
 	mazeStyle.Render("\u2588\u2588"),
@@ -40,125 +30,194 @@ var valToString = [5]string{
 	mazeStyle.Render("🚪"),
 }
 
-// this is our data model
-// every MazeModel cell is a string of two runes
-type MazeModel struct {
-	cells     []cellContent
-	width     int
-	height    int
-	playerX   int
-	playerY   int
-	treasureX int
-	treasureY int
-	StepsDone int // exported step counter
-	doorsX    [nDoors]int
-	doorsY    [nDoors]int
+// indexed by the stairs bits
+var stairsToString = [4]string{
+	"",
+	stairsStyle.Render(" ▲"),
+	stairsStyle.Render(" ▼"),
+	stairsStyle.Render("▲▼"),
 }
 
-func NewMaze(w, h int) MazeModel {
-	cells := make([]cellContent, w*h)
-	m := MazeModel{cells: cells, width: w, height: h, StepsDone: 0}
-	// draw borders
-	for x := 0; x < w; x++ {
-		m.set(x, 0, 1)
-		m.set(x, h-1, 1)
-	}
-	for y := 0; y < h; y++ {
-		m.set(0, y, 1)
-		m.set(w-1, y, 1)
-	}
-	// draw grid on alternate cells
-	for x := 2; x < w-2; x += 2 {
-		for y := 1; y < h-1; y++ {
-			m.set(x, y, 1)
-		}
+// the two ends of a portal have the same color, different from the other
+// portals, and so do a key and its door: SUSE Jungle, Persimmon and Fog,
+// then yellow, pink, red, purple, cyan and brown. One for each of the
+// 9 portals a level can have
+var pairColors = []lipgloss.Color{"#30ba78", "#fe7c3f", "#efefef", "#f3d03e", "#f78fb3", "#ff4f4f", "#b48cf2", "#5fd7ff", "#a67c52"}
+
+// the glyph in each of the pairColors
+func paired(glyph string) []string {
+	s := make([]string, len(pairColors))
+	for i, c := range pairColors {
+		s[i] = lipgloss.NewStyle().Background(commonBG).Foreground(c).Render(glyph)
 	}
-	for y := 2; y < h-2; y += 2 {
-		for x := 1; x < w-1; x++ {
-			m.set(x, y, 1)
-		}
+	return s
+}
+
+// the blocks out of sight: the ones seen before keep their walls, doors and
+// stairs, dimmed, the others are dark
+var dimStyle = lipgloss.NewStyle().Background(commonBG).Foreground(lipgloss.Color("#17338f"))
+var darkness = mazeStyle.Render("  ")
+
+var torchToString = mazeStyle.Render("🔦")
+
+// indexed by the number of the portal, key or locked door
+var portalToString = paired("()")
+var keyToString = paired("o-")
+var lockToString = paired("▒▒")
+
+// the lines under the maze: status and inventory
+const footerHeight = 2
+
+// the keys moving the player
+var keyDirections = map[string]engine.Direction{
+	"up":     engine.North,
+	"right":  engine.East,
+	"down":   engine.South,
+	"left":   engine.West,
+	"<":      engine.Up,
+	"pgup":   engine.Up,
+	">":      engine.Down,
+	"pgdown": engine.Down,
+}
+
+// this is our data model: the game in the terminal.
+// The rules are in the engine, here the maze is drawn
+// and the keys become moves
+type MazeModel struct {
+	game *engine.Game // nil until the terminal size is known
+	opts engine.Options
+	err  error // why there's no maze for this terminal
+	// terminal size, 0 until known
+	termWidth  int
+	termHeight int
+}
+
+// a game, when nil one is generated with opts to fill the terminal
+func NewMaze(game *engine.Game, opts engine.Options) MazeModel {
+	return MazeModel{game: game, opts: opts}
+}
+
+// the game being played
+func (m MazeModel) Game() *engine.Game {
+	return m.game
+}
+
+// the error that stopped the game, if any
+func (m MazeModel) Err() error {
+	return m.err
+}
+
+// returns a string representing our model,
+// only the part around the player when the maze is bigger than the terminal
+// every maze cell is a string of two runes
+func (m MazeModel) View() string {
+	if m.game == nil {
+		return ""
 	}
-	// starting on the upper row, for each cell
-	// flip a coin in order to decide which direction to carve
-	for y := 1; y < h-2; y += 2 {
-		for x := 1; x < w-2; x += 2 {
-			if rand.Intn(2) == 1 {
-				m.set(x+1, y, 0)
-			} else {
-				m.set(x, y+1, 0)
+	var sb strings.Builder
+	left, top, w, h := m.camera()
+	for y := top; y < top+h; y++ {
+		for x := left; x < left+w; x++ {
+			c := m.game.At(x, y)
+			if !m.game.Visible(x, y) {
+				sb.WriteString(m.remembered(x, y))
+				continue
+			}
+			if c == engine.TorchCell {
+				sb.WriteString(torchToString)
+				continue
 			}
+			if n := m.game.PortalAt(x, y); n >= 0 && c == engine.PortalCell {
+				sb.WriteString(portalToString[n%len(portalToString)])
+				continue
+			}
+			if n := m.game.KeyAt(x, y); n >= 0 && c == engine.KeyCell {
+				sb.WriteString(keyToString[n%len(keyToString)])
+				continue
+			}
+			if n := m.game.LockAt(x, y); n >= 0 && c == engine.LockCell {
+				sb.WriteString(lockToString[n%len(lockToString)])
+				continue
+			}
+			if s := m.game.StairsAt(x, y); s != 0 && c == engine.EmptyCell {
+				sb.WriteString(stairsToString[s])
+				continue
+			}
+			sb.WriteString(valToString[c])
 		}
+		sb.WriteRune('\n')
 	}
-	// carve some extra random spots
-	for i := 0; i < (w*h)/5; i++ {
-		m.set(1+rand.Intn(w-2), 1+rand.Intn(h-2), 0)
-	}
-	// drop some doors (at random)
-	for i := 0; i < nDoors; i++ {
-		m.doorsX[i] = 3 + rand.Intn(w-4)
-		m.doorsY[i] = 3 + rand.Intn(h-4)
-		m.set(m.doorsX[i], m.doorsY[i], DoorCell)
-	}
-	//place player (+/- in the center)
-	m.playerX, m.playerY = w/2, h/2
-	m.set(m.playerX, m.playerY, PlayerCell)
-	//treasure (in random place)
-	var x, y int
-	for x != m.playerX && y != m.playerY {
-		x = 3 + rand.Intn(w-4)
-		y = 3 + rand.Intn(h-4)
-	}
-	m.treasureX, m.treasureY = x, y
-	m.set(m.treasureX, m.treasureY, TreasureCell)
-	return m
+	sb.WriteString(m.status() + "\n" + m.inventory())
+	return sb.String()
 }
 
-func (m *MazeModel) set(x, y int, value cellContent) {
-	i := y*m.width + x
-	if i > len(m.cells)-1 || x < 0 || y < 0 || x >= m.width || y >= m.height {
-		return
+// a block out of sight, as the player remembers it
+func (m MazeModel) remembered(x, y int) string {
+	if !m.game.Seen(x, y) {
+		return darkness
 	}
-	m.cells[i] = value
-}
-
-func (m *MazeModel) get(x, y int) cellContent {
-	i := y*m.width + x
-	if i > len(m.cells)-1 || x < 0 || y < 0 || x >= m.width || y >= m.height {
-		return 1
+	c := m.game.At(x, y)
+	switch {
+	case c == engine.WallCell:
+		return dimStyle.Render("\u2588\u2588")
+	case c == engine.LockCell:
+		return dimStyle.Render("▒▒")
+	case c == engine.PortalCell:
+		return dimStyle.Render("()")
+	case m.game.StairsAt(x, y) == engine.StairsUp:
+		return dimStyle.Render(" ▲")
+	case m.game.StairsAt(x, y) == engine.StairsDown:
+		return dimStyle.Render(" ▼")
+	case m.game.StairsAt(x, y) != 0:
+		return dimStyle.Render("▲▼")
 	}
-	return m.cells[i]
+	return darkness
 }
 
-func (m *MazeModel) checkCollisions() (tea.Model, tea.Cmd) {
-	m.StepsDone += 1
-	for i := 0; i < nDoors; i++ {
-		if m.playerX == m.doorsX[i] && m.playerY == m.doorsY[i] {
-			m.set(m.playerX, m.playerY, EmptyCell)
-			m.doorsX[i], m.doorsY[i] = 0, 0
-			m.playerX = m.width / 2
-			m.playerY = m.height / 2
-			m.set(m.playerX, m.playerY, PlayerCell)
+// the panel with the items carried by the player
+func (m MazeModel) inventory() string {
+	items := m.game.Inventory()
+	if len(items) == 0 {
+		return "inventory: empty"
+	}
+	s := "inventory:"
+	for _, item := range items {
+		switch item.Kind {
+		case engine.KeyItem:
+			s += " " + keyToString[item.Number%len(keyToString)]
+		case engine.TorchItem:
+			s += " " + torchToString
 		}
 	}
-	if m.playerX == m.treasureX && m.playerY == m.treasureY {
-		return m, tea.Quit
+	return s
+}
+
+// the part of the maze on screen: upper left corner and size.
+// The player stays in the middle until the camera reaches the border
+func (m MazeModel) camera() (int, int, int, int) {
+	width, height := m.game.Width(), m.game.Height()
+	if m.termWidth == 0 {
+		return 0, 0, width, height
 	}
-	return m, nil
+	// every maze cell is 2 chars, and the footer is under the maze
+	w, h := min(width, m.termWidth/2), min(height, m.termHeight-footerHeight)
+	x, y := m.game.Player()
+	left := min(max(x-w/2, 0), width-w)
+	top := min(max(y-h/2, 0), height-h)
+	return left, top, w, h
 }
 
-// returns a string representing our model
-func (m MazeModel) View() string {
-	var sb strings.Builder
-	for y := 0; y < m.height; y++ {
-		for x := 0; x < m.width; x++ {
-			i := x + y*m.width
-			sb.WriteString(valToString[m.cells[i]])
-		}
-		if y < m.height-1 {
-			sb.WriteRune('\n')
-		}
+// a line with the information needed to reproduce the maze
+func (m MazeModel) status() string {
+	g, opts := m.game, m.game.Options()
+	if g.Level() != "" {
+		return fmt.Sprintf("%s %dx%d - %s - steps %d", g.Level(), g.Width(), g.Height(), g.Difficulty(), g.Steps())
 	}
-	return sb.String()
+	if g.FloorCount() > 1 {
+		return fmt.Sprintf("%dx%dx%d seed %d braid %g - %s - floor %d/%d - steps %d - < up, > down",
+			g.Width(), g.Height(), g.FloorCount(), opts.Seed, opts.Braid, g.Difficulty(), g.Floor()+1, g.FloorCount(), g.Steps())
+	}
+	return fmt.Sprintf("%dx%d seed %d braid %g - %s - steps %d", g.Width(), g.Height(), opts.Seed, opts.Braid, g.Difficulty(), g.Steps())
 }
 
 // nothing to do on startup
@@ -174,34 +233,37 @@ func (m MazeModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if msg.Type == tea.KeyCtrlC || msg.Type == tea.KeyEsc {
 			return &m, tea.Quit
 		}
-		if msg.Type == tea.KeyUp && m.get(m.playerX, m.playerY-1)%2 == 0 {
-			m.set(m.playerX, m.playerY, 0)
-			m.playerY -= 1
-			m.set(m.playerX, m.playerY, 3)
-			return m.checkCollisions()
-		}
-		if msg.Type == tea.KeyDown && m.get(m.playerX, m.playerY+1)%2 == 0 {
-			m.set(m.playerX, m.playerY, 0)
-			m.playerY += 1
-			m.set(m.playerX, m.playerY, 3)
-			return m.checkCollisions()
+		d, ok := keyDirections[msg.String()]
+		if !ok || m.game == nil {
+			return m, nil
 		}
-		if msg.Type == tea.KeyLeft && m.get(m.playerX-1, m.playerY)%2 == 0 {
-			m.set(m.playerX, m.playerY, 0)
-			m.playerX -= 1
-			m.set(m.playerX, m.playerY, 3)
-			return m.checkCollisions()
-		}
-		if msg.Type == tea.KeyRight && m.get(m.playerX+1, m.playerY)%2 == 0 {
-			m.set(m.playerX, m.playerY, 0)
-			m.playerX += 1
-			m.set(m.playerX, m.playerY, 3)
-			return m.checkCollisions()
+		if slices.Contains(m.game.Move(d), engine.Won) {
+			return &m, tea.Quit
 		}
 	case tea.WindowSizeMsg:
+		m.termWidth, m.termHeight = msg.Width, msg.Height
+		opts := m.opts
+		// a loaded level and a maze with a given size keep it
+		if m.game != nil && (m.game.Level() != "" || (opts.Width > 0 && opts.Height > 0)) {
+			return m, nil
+		}
 		// on resize, generate a new Maze
-		// half width because every maze cell is 2 chars
-		return NewMaze(msg.Width/2, msg.Height), nil
+		// half width because every maze cell is 2 chars,
+		// and keep the last lines for the footer
+		w, h := msg.Width/2, msg.Height-footerHeight
+		if opts.Width > 0 {
+			w = opts.Width
+		}
+		if opts.Height > 0 {
+			h = opts.Height
+		}
+		game, err := engine.NewGame(w, h, opts)
+		if err != nil {
+			m.err = err
+			return &m, tea.Quit
+		}
+		m.game = game
+		return m, nil
 	}
 	return m, nil
 }
@@ -1,19 +1,78 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ilmanzo/hackweek24/a_maze/game/engine"
 	maze "github.com/ilmanzo/hackweek24/a_maze/game/internal"
 )
 
 // main purpose of this projects is to learn and explore the Go import rules and directory structure
 
 func main() {
-	p := tea.NewProgram(
-		maze.NewMaze(20, 20), tea.WithAltScreen(),
-	)
+	seed := flag.Int64("seed", 0, "random seed, 0 picks a new one")
+	braid := flag.Float64("braid", 0.2, "fraction of the dead ends removed to make loops, from 0 for a perfect maze to 1 for none")
+	width := flag.Int("width", 0, "maze width in cells of 2 chars, 0 fills the terminal; bigger mazes scroll")
+	height := flag.Int("height", 0, "maze height in lines, 0 fills the terminal; bigger mazes scroll")
+	floors := flag.Int("floors", 1, "floors of the maze, joined by stairs")
+	difficulty := flag.String("difficulty", "", "generate mazes until one is "+strings.Join(engine.Difficulties, ", ")+" to play, from the player to the treasure")
+	distance := flag.Int("distance", 0, "steps from the player to the treasure at least, 0 for a quarter of width and height")
+	light := flag.Int("light", engine.DefaultLight, "how far the player sees in blocks, every torch picked up adds some more")
+	level := flag.String("level", "", "play a hand-designed level (.txt, .json or .png) instead of a random maze")
+	flag.Parse()
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	if *braid < 0 || *braid > 1 {
+		fmt.Println("The braid must be between 0 and 1")
+		os.Exit(1)
+	}
+	if (*width != 0 && *width < 7) || (*height != 0 && *height < 7) {
+		fmt.Println("The maze must be at least 7x7")
+		os.Exit(1)
+	}
+	if *floors < 1 || *floors > 9 {
+		fmt.Println("The floors must be between 1 and 9")
+		os.Exit(1)
+	}
+	if *difficulty != "" && !slices.Contains(engine.Difficulties, *difficulty) {
+		fmt.Printf("Unknown difficulty %q, choose one of: %s\n", *difficulty, strings.Join(engine.Difficulties, ", "))
+		os.Exit(1)
+	}
+	if *light < 1 {
+		fmt.Println("The light must be at least 1")
+		os.Exit(1)
+	}
+	if *distance < 0 {
+		fmt.Println("The distance can't be negative")
+		os.Exit(1)
+	}
+	if *difficulty != "" && *level != "" {
+		fmt.Println("A level has its own difficulty, -difficulty only works with random mazes")
+		os.Exit(1)
+	}
+	opts := engine.Options{Seed: *seed, Braid: *braid, Width: *width, Height: *height, Floors: *floors, Difficulty: *difficulty, MinDistance: *distance, Light: *light}
+	// a maze filling the terminal waits for its size
+	var game *engine.Game
+	var err error
+	if *level != "" {
+		if game, err = engine.LoadGame(*level, opts); err != nil {
+			fmt.Printf("Can't load the level: %v\n", err)
+			os.Exit(1)
+		}
+	} else if *width > 0 && *height > 0 {
+		if game, err = engine.NewGame(*width, *height, opts); err != nil {
+			fmt.Printf("Whops, there's been an error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	p := tea.NewProgram(maze.NewMaze(game, opts), tea.WithAltScreen())
 	m, err := p.Run()
 	if err != nil {
 		fmt.Printf("Whops, there's been an error: %v", err)
@@ -25,5 +84,27 @@ func main() {
 		fmt.Printf("Unexpected model type: %T\n", maze)
 		os.Exit(1)
 	}
-	fmt.Printf("===========================================\nGood! You walked %d steps to get the ticket\n", maze.StepsDone)
+	if maze.Err() != nil {
+		fmt.Printf("Whops, there's been an error: %v\n", maze.Err())
+		os.Exit(1)
+	}
+	game = maze.Game()
+	if game == nil {
+		return
+	}
+	fmt.Printf("===========================================\nGood! You walked %d steps to get the ticket\n", game.Steps())
+	fmt.Print("Here's the map of the maze:\n" + game.Map())
+	if game.Level() != "" {
+		return
+	}
+	// everything that shapes the maze, the size too when it filled the terminal
+	o := game.Options()
+	again := fmt.Sprintf("-seed %d -braid %g -width %d -height %d -floors %d", o.Seed, o.Braid, game.Width(), game.Height(), game.FloorCount())
+	if o.Difficulty != "" {
+		again += " -difficulty " + o.Difficulty
+	}
+	if o.MinDistance != 0 {
+		again += fmt.Sprintf(" -distance %d", o.MinDistance)
+	}
+	fmt.Printf("Play this maze again with %s\n", again)
 }
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// shows the generation one carving step at a time,
+// the finished maze is generated upfront and then replayed
+
+// the cell carved by the last step, in SUSE Persimmon
+var activeColor = lipgloss.Color("#fe7c3f")
+
+const (
+	defaultDelay = 30 * time.Millisecond
+	minDelay     = time.Millisecond
+	maxDelay     = time.Second
+)
+
+type animation struct {
+	final   maze // the finished maze, with all the steps recorded
+	current maze // the maze up to the current step
+	step    int  // number of steps already carved
+	paused  bool
+	delay   time.Duration
+	frame   int // id of the running timer, older frames are ignored
+}
+
+// like in bouncing_ball, but every frame knows which timer sent it
+type frameMsg struct {
+	id int
+}
+
+func (a animation) animate() tea.Cmd {
+	id := a.frame
+	return tea.Tick(a.delay, func(_ time.Time) tea.Msg {
+		return frameMsg{id}
+	})
+}
+
+func newAnimation(w, h int, opts options) animation {
+	a := animation{final: generate(w, h, opts, true), delay: defaultDelay}
+	a.restart()
+	return a
+}
+
+// starts again from a completely filled maze
+func (a *animation) restart() {
+	a.current = a.final
+	a.current.cells = make([]byte, len(a.final.cells))
+	for i := range a.current.cells {
+		a.current.cells[i] = 1
+	}
+	// a new grid, without passages
+	if a.final.under != nil {
+		a.current.under = map[int]bool{}
+	}
+	a.current.shape = newShape(a.final.opts.grid, a.final.width, a.final.height)
+	if a.current.shape != nil {
+		a.current.draw()
+	}
+	a.step = 0
+}
+
+func (a animation) done() bool {
+	return a.step >= len(a.final.steps)
+}
+
+// carves the next step, the last one also adds the final touches
+func (a *animation) advance() {
+	if a.done() {
+		return
+	}
+	s := a.final.steps[a.step]
+	a.current.grid().link(s.a, s.b)
+	if a.current.shape != nil {
+		a.current.draw()
+	}
+	a.step++
+	if a.done() {
+		copy(a.current.cells, a.final.cells)
+	}
+}
+
+func (a animation) Init() tea.Cmd {
+	return a.animate()
+}
+
+func (a animation) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			return a, tea.Quit
+		case " ":
+			a.paused = !a.paused
+		case "n", "right":
+			a.paused = true
+			a.advance()
+			return a, nil
+		case "+":
+			a.delay = max(a.delay/2, minDelay)
+		case "-":
+			a.delay = min(a.delay*2, maxDelay)
+		case "r":
+			a.restart()
+		default:
+			return a, nil
+		}
+		// restart the timer, so changes take effect at once
+		a.frame++
+		if a.paused {
+			return a, nil
+		}
+		return a, a.animate()
+	case tea.WindowSizeMsg:
+		a.final = generate(msg.Width, (msg.Height-1)*2, a.final.opts, true)
+		a.restart()
+		a.frame++
+		return a, a.animate()
+	case frameMsg:
+		if msg.id != a.frame || a.paused || a.done() {
+			return a, nil
+		}
+		a.advance()
+		return a, a.animate()
+	}
+	return a, nil
+}
+
+func (a animation) View() string {
+	ax, ay := -1, -1
+	if a.step > 0 && !a.done() {
+		ax, ay = a.current.center(a.final.steps[a.step-1].b)
+	}
+	maze := a.current.render(halfBlocks{}, a.current.whole(), func(x, y int) lipgloss.TerminalColor {
+		if x == ax && y == ay {
+			return activeColor
+		}
+		return nil
+	})
+	state := fmt.Sprintf("%v", a.delay)
+	if a.paused {
+		state = "paused"
+	}
+	return fmt.Sprintf("%s\n%s - step %d/%d %s - space pause, n step, +/- speed, r restart, q quit",
+		maze, a.current.status(), a.step, len(a.final.steps), state)
+}
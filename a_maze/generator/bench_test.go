@@ -0,0 +1,67 @@
+package main
+
+import (
+	"io"
+	"testing"
+)
+
+// compares the byte per pixel maze with the bits used for huge mazes,
+// run with go test -bench . -benchmem
+
+// pixels of the benchmark mazes, big enough to leave the CPU caches
+const benchSize = 2001
+
+func BenchmarkGenerateBytes(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		NewMaze(benchSize, benchSize, options{algo: "binarytree", seed: int64(i)})
+	}
+}
+
+func BenchmarkGenerateBits(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		binaryTreeBits(benchSize, benchSize, int64(i))
+	}
+}
+
+func BenchmarkReadBytes(b *testing.B) {
+	m := NewMaze(benchSize, benchSize, options{algo: "binarytree", seed: 1})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		walls := 0
+		for y := 0; y < m.height; y++ {
+			for x := 0; x < m.width; x++ {
+				walls += int(m.get(x, y))
+			}
+		}
+	}
+}
+
+func BenchmarkReadBits(b *testing.B) {
+	g := binaryTreeBits(benchSize, benchSize, 1)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		walls := 0
+		for y := 0; y < g.height; y++ {
+			for x := 0; x < g.width; x++ {
+				walls += int(g.get(x, y))
+			}
+		}
+	}
+}
+
+func BenchmarkBlocksString(b *testing.B) {
+	m := NewMaze(benchSize, benchSize, options{algo: "binarytree", seed: 1})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		io.WriteString(io.Discard, m.toString(halfBlocks{}, m.whole()))
+	}
+}
+
+func BenchmarkBlocksStream(b *testing.B) {
+	g := binaryTreeBits(benchSize, benchSize, 1)
+	wall := func(x, y int) bool { return g.get(x, y) == 1 }
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		writeText(io.Discard, g.width, g.height, wall, halfBlocks{})
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"maps"
+	"math/rand"
+	"slices"
+)
+
+// the pixels of a maze packed 64 in a word, for the mazes too big for
+// a byte per pixel: a 20000x20000 maze takes 50MB instead of 400MB.
+// Only the headless generator uses it, see hugeMaze
+type bitGrid struct {
+	width, height int
+	words         []uint64
+}
+
+// mazes with more pixels than this are generated as bits and streamed
+// to the output, the other ones go through the usual maze
+const hugeMaze = 4096 * 4096
+
+// the algorithms that can carve a bitGrid: they only look at the cell or
+// at the row they're carving, so they need little memory besides the pixels.
+// Each one gives the same maze as the generator with its name
+var bitGenerators = map[string]func(w, h int, seed int64) *bitGrid{
+	"binarytree": binaryTreeBits,
+	"eller":      ellerBits,
+}
+
+// completely filled, like every maze before carving
+func newBitGrid(w, h int) *bitGrid {
+	g := &bitGrid{width: w, height: h, words: make([]uint64, (w*h+63)/64)}
+	for i := range g.words {
+		g.words[i] = ^uint64(0)
+	}
+	return g
+}
+
+// outside of the maze everything is filled, like in maze.get
+func (g *bitGrid) get(x, y int) byte {
+	if x < 0 || y < 0 || x >= g.width || y >= g.height {
+		return 1
+	}
+	i := y*g.width + x
+	return byte(g.words[i/64] >> (i % 64) & 1)
+}
+
+func (g *bitGrid) set(x, y int, value byte) {
+	if x < 0 || y < 0 || x >= g.width || y >= g.height {
+		return
+	}
+	i := y*g.width + x
+	if value == 0 {
+		g.words[i/64] &^= 1 << (i % 64)
+	} else {
+		g.words[i/64] |= 1 << (i % 64)
+	}
+}
+
+// the same maze as the binarytree generator on the rect grid, carved
+// straight into the bits. Each cell links east or south, like there
+// the random numbers are drawn in the same order, so the seed gives
+// the same maze
+func binaryTreeBits(w, h int, seed int64) *bitGrid {
+	g := newBitGrid(w, h)
+	rng := rand.New(rand.NewSource(seed))
+	cols, rows := max(0, (w-1)/2), max(0, (h-1)/2)
+	for cy := 0; cy < rows; cy++ {
+		for cx := 0; cx < cols; cx++ {
+			x, y := 2*cx+1, 2*cy+1
+			east, south := cx < cols-1, cy < rows-1
+			var dx, dy int
+			switch {
+			case east && south:
+				if rng.Intn(2) == 0 {
+					dx = 1
+				} else {
+					dy = 1
+				}
+			case east:
+				rng.Intn(1)
+				dx = 1
+			case south:
+				rng.Intn(1)
+				dy = 1
+			default:
+				continue
+			}
+			// like maze.link, both cells and the wall between them
+			g.set(x, y, 0)
+			g.set(x+dx, y+dy, 0)
+			g.set(x+2*dx, y+2*dy, 0)
+		}
+	}
+	return g
+}
+
+// the sorted names of the bitGenerators
+func bitGeneratorNames() []string {
+	return slices.Sorted(maps.Keys(bitGenerators))
+}
+
+// the same maze as the eller generator on the rect grid, carved straight
+// into the bits with the random numbers drawn in the same order.
+// Only the sets of the row being carved are kept, numbered again from 0
+// on every row, so a set is never more than a column
+func ellerBits(w, h int, seed int64) *bitGrid {
+	g := newBitGrid(w, h)
+	rng := rand.New(rand.NewSource(seed))
+	cols, rows := max(0, (w-1)/2), max(0, (h-1)/2)
+	// like maze.link, from cell cx,cy east or south
+	link := func(cx, cy, dx, dy int) {
+		x, y := 2*cx+1, 2*cy+1
+		g.set(x, y, 0)
+		g.set(x+dx, y+dy, 0)
+		g.set(x+2*dx, y+2*dy, 0)
+	}
+	// the set of each cell of the row and of the one below, -1 for none yet
+	sets, below := make([]int, cols), make([]int, cols)
+	renumber, group := make([]int, cols), make([]int, cols)
+	members := make([][]int, cols)
+	joined := newDisjointSet(cols)
+	for cx := range sets {
+		sets[cx], below[cx] = -1, -1
+	}
+	for cy := 0; cy < rows; cy++ {
+		// the sets in order of first appearance, new cells in sets of their own
+		for i := range renumber {
+			renumber[i] = -1
+		}
+		next := 0
+		for cx, s := range sets {
+			if s >= 0 && renumber[s] < 0 {
+				renumber[s], next = next, next+1
+			}
+			if s >= 0 {
+				sets[cx] = renumber[s]
+			} else {
+				sets[cx], next = next, next+1
+			}
+		}
+		for i := range joined {
+			joined[i] = i
+		}
+		last := cy == rows-1
+		// randomly join adjacent cells, always on the last row
+		for cx := 0; cx < cols-1; cx++ {
+			if joined.find(sets[cx]) == joined.find(sets[cx+1]) || (!last && rng.Intn(2) == 0) {
+				continue
+			}
+			link(cx, cy, 1, 0)
+			joined.union(sets[cx], sets[cx+1])
+		}
+		if last {
+			break
+		}
+		// every set carves at least one passage down, in order of first appearance
+		for i := range group {
+			group[i], members[i] = -1, members[i][:0]
+		}
+		groups := 0
+		for cx, s := range sets {
+			root := joined.find(s)
+			if group[root] < 0 {
+				group[root], groups = groups, groups+1
+			}
+			members[group[root]] = append(members[group[root]], cx)
+		}
+		for n, down := range members[:groups] {
+			rng.Shuffle(len(down), func(i, j int) { down[i], down[j] = down[j], down[i] })
+			for _, cx := range down[:1+rng.Intn(len(down))] {
+				link(cx, cy, 0, 1)
+				below[cx] = n
+			}
+		}
+		sets, below = below, sets
+		for cx := range below {
+			below[cx] = -1
+		}
+	}
+	return g
+}
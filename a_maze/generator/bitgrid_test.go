@@ -0,0 +1,23 @@
+package main
+
+import "testing"
+
+// the bits are the same maze as the bytes, pixel by pixel
+func TestBitGenerators(t *testing.T) {
+	for name, generate := range bitGenerators {
+		for _, size := range [][2]int{{3, 3}, {21, 11}, {40, 17}, {61, 60}} {
+			for seed := int64(1); seed <= 5; seed++ {
+				w, h := size[0], size[1]
+				m := NewMaze(w, h, options{algo: name, seed: seed})
+				g := generate(w, h, seed)
+				for y := 0; y < h; y++ {
+					for x := 0; x < w; x++ {
+						if m.get(x, y) != g.get(x, y) {
+							t.Fatalf("%s %dx%d seed %d: pixel %d,%d is %d in the bits, %d in the bytes", name, w, h, seed, x, y, g.get(x, y), m.get(x, y))
+						}
+					}
+				}
+			}
+		}
+	}
+}
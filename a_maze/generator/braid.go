@@ -0,0 +1,68 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+)
+
+// a braid maze has loops instead of some of its dead ends.
+// Each removed dead end is linked to one of its neighbours, preferring
+// the ones that are dead ends too, so a single link removes both.
+// Corner cells with a single neighbour, like in the triangle grid,
+// stay dead ends whatever the fraction
+func braid(g grid, fraction float64, rng *rand.Rand) {
+	var deadEnds []int
+	for _, c := range g.activeCells() {
+		if exits(g, c) == 1 {
+			deadEnds = append(deadEnds, c)
+		}
+	}
+	rng.Shuffle(len(deadEnds), func(i, j int) { deadEnds[i], deadEnds[j] = deadEnds[j], deadEnds[i] })
+	left := int(math.Round(fraction * float64(len(deadEnds))))
+	for _, c := range deadEnds {
+		if left <= 0 {
+			break
+		}
+		// it may be gone with one of the links before
+		if exits(g, c) != 1 {
+			continue
+		}
+		var closed, alsoDeadEnds []int
+		for _, n := range g.neighbours(c) {
+			if g.linked(c, n) {
+				continue
+			}
+			closed = append(closed, n)
+			if exits(g, n) == 1 {
+				alsoDeadEnds = append(alsoDeadEnds, n)
+			}
+		}
+		// two at once only if both have to go
+		if len(alsoDeadEnds) > 0 && left >= 2 {
+			g.link(c, alsoDeadEnds[rng.Intn(len(alsoDeadEnds))])
+			left -= 2
+			continue
+		}
+		var others []int
+		for _, n := range closed {
+			if exits(g, n) != 1 {
+				others = append(others, n)
+			}
+		}
+		if len(others) > 0 {
+			g.link(c, others[rng.Intn(len(others))])
+			left--
+		}
+	}
+}
+
+// the number of passages out of a cell
+func exits(g grid, c int) int {
+	n := 0
+	for _, nb := range g.neighbours(c) {
+		if g.linked(c, nb) {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,122 @@
+package main
+
+// the pixel grid of a maze is seen as a rectangular grid of cells
+// separated by walls, see grid.go for the other topologies:
+// cells sit on odd coordinates, the pixels in between are the walls.
+// Cells are numbered row by row, starting from 0 in the upper left corner.
+// With a mask only some of the cells are part of the maze, the others
+// stay filled and have no neighbours
+
+// number of cell columns
+func (m *maze) cols() int {
+	return max(0, (m.width-1)/2)
+}
+
+// number of cell rows
+func (m *maze) rows() int {
+	return max(0, (m.height-1)/2)
+}
+
+func (m *maze) cellCount() int {
+	return m.cols() * m.rows()
+}
+
+// true if the cell is part of the maze
+func (m *maze) active(c int) bool {
+	return m.mask == nil || m.mask[c]
+}
+
+// the cells that are part of the maze, in order
+func (m *maze) activeCells() []int {
+	result := make([]int, 0, m.cellCount())
+	for c := 0; c < m.cellCount(); c++ {
+		if m.active(c) {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// true if the pixel touches an active cell: walls around the maze
+// are drawn, the area outside of the mask or of the shape is left empty
+func (m *maze) visible(x, y int) bool {
+	if m.shape != nil {
+		return m.shape.cellAtPixel(x, y) >= 0
+	}
+	if m.mask == nil {
+		return true
+	}
+	for cy := (y - 1) / 2; cy <= y/2; cy++ {
+		for cx := (x - 1) / 2; cx <= x/2; cx++ {
+			if cx >= 0 && cy >= 0 && cx < m.cols() && cy < m.rows() && m.mask[m.cellAt(cx, cy)] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (m *maze) cellAt(cx, cy int) int {
+	return cy*m.cols() + cx
+}
+
+func (m *maze) cellXY(c int) (int, int) {
+	return c % m.cols(), c / m.cols()
+}
+
+// pixel coordinates of the center of a cell
+func (m *maze) pixelXY(c int) (int, int) {
+	cx, cy := m.cellXY(c)
+	return 2*cx + 1, 2*cy + 1
+}
+
+// returns the adjacent active cells: north, south, west and east
+func (m *maze) neighbours(c int) []int {
+	cx, cy := m.cellXY(c)
+	result := make([]int, 0, 4)
+	add := func(cx, cy int) {
+		if n := m.cellAt(cx, cy); m.active(n) {
+			result = append(result, n)
+		}
+	}
+	if cy > 0 {
+		add(cx, cy-1)
+	}
+	if cy < m.rows()-1 {
+		add(cx, cy+1)
+	}
+	if cx > 0 {
+		add(cx-1, cy)
+	}
+	if cx < m.cols()-1 {
+		add(cx+1, cy)
+	}
+	return result
+}
+
+// carves two adjacent cells and the wall between them
+func (m *maze) link(a, b int) {
+	ax, ay := m.pixelXY(a)
+	bx, by := m.pixelXY(b)
+	m.set(ax, ay, 0)
+	m.set(bx, by, 0)
+	m.set((ax+bx)/2, (ay+by)/2, 0)
+}
+
+// true if there's a passage between two adjacent cells
+func (m *maze) linked(a, b int) bool {
+	ax, ay := m.pixelXY(a)
+	bx, by := m.pixelXY(b)
+	return m.get((ax+bx)/2, (ay+by)/2) == 0
+}
+
+// all the cells of each row, including the ones outside of the mask
+func (m *maze) cellRows() [][]int {
+	rows := make([][]int, m.rows())
+	for cy := range rows {
+		for cx := 0; cx < m.cols(); cx++ {
+			rows[cy] = append(rows[cy], m.cellAt(cx, cy))
+		}
+	}
+	return rows
+}
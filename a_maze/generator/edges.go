@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// the cells of a rectangular maze and the passages between them, like in
+// "Mazes for Programmers": no room wasted on the walls, every cell knows
+// its linked neighbours. The block model in cells.go is the pixel view
+// of the same maze, and the two can be converted back and forth
+type edgeGrid struct {
+	width, height int     // in cells
+	mask          []bool  // active cells, nil when they all are
+	links         []uint8 // the open sides of each cell, see side
+}
+
+// bit of each side in links, in the order of directions:
+// 1 north, 2 east, 4 south, 8 west, the same as passageGlyphs
+func side(d int) uint8 {
+	return 1 << d
+}
+
+func newEdgeGrid(w, h int) *edgeGrid {
+	return &edgeGrid{width: w, height: h, links: make([]uint8, w*h)}
+}
+
+func (e *edgeGrid) cellCount() int {
+	return e.width * e.height
+}
+
+func (e *edgeGrid) active(c int) bool {
+	return e.mask == nil || e.mask[c]
+}
+
+func (e *edgeGrid) activeCells() []int {
+	result := make([]int, 0, e.cellCount())
+	for c := 0; c < e.cellCount(); c++ {
+		if e.active(c) {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// the cell next to c in the direction d, false at the border
+func (e *edgeGrid) step(c, d int) (int, bool) {
+	x, y := c%e.width+directions[d].x, c/e.width+directions[d].y
+	if x < 0 || y < 0 || x >= e.width || y >= e.height {
+		return 0, false
+	}
+	return y*e.width + x, true
+}
+
+// the direction from a to b, -1 if they are not adjacent
+func (e *edgeGrid) toward(a, b int) int {
+	for d := range directions {
+		if n, ok := e.step(a, d); ok && n == b {
+			return d
+		}
+	}
+	return -1
+}
+
+func (e *edgeGrid) neighbours(c int) []int {
+	result := make([]int, 0, 4)
+	for d := range directions {
+		if n, ok := e.step(c, d); ok && e.active(n) {
+			result = append(result, n)
+		}
+	}
+	return result
+}
+
+func (e *edgeGrid) link(a, b int) {
+	if d := e.toward(a, b); d >= 0 {
+		e.links[a] |= side(d)
+		e.links[b] |= side((d + 2) % 4)
+	}
+}
+
+func (e *edgeGrid) linked(a, b int) bool {
+	d := e.toward(a, b)
+	return d >= 0 && e.links[a]&side(d) != 0
+}
+
+func (e *edgeGrid) cellRows() [][]int {
+	rows := make([][]int, e.height)
+	for y := range rows {
+		for x := 0; x < e.width; x++ {
+			rows[y] = append(rows[y], y*e.width+x)
+		}
+	}
+	return rows
+}
+
+// the cells and passages of a maze made of blocks. Fails when the pixels
+// are not laid out as cells on odd coordinates with walls in between,
+// like the mazes drawn by hand, or for the grids with their own geometry.
+// With an even size the last filled column or row is dropped
+func (m *maze) edges() (*edgeGrid, error) {
+	if m.shape != nil || m.under != nil {
+		return nil, fmt.Errorf("only the rect grid has edges, this one is %s", m.opts.grid)
+	}
+	e := newEdgeGrid(m.cols(), m.rows())
+	e.mask = m.mask
+	w, h := 2*e.width+1, 2*e.height+1
+	for y := 0; y < m.height; y++ {
+		for x := 0; x < m.width; x++ {
+			open := m.get(x, y) == 0
+			switch {
+			case x >= w || y >= h:
+				if open {
+					return nil, fmt.Errorf("pixel %d,%d is outside of the cells, it can't be open", x, y)
+				}
+			case x%2 == 1 && y%2 == 1:
+				if open != e.active(m.cellAt(x/2, y/2)) {
+					return nil, fmt.Errorf("pixel %d,%d is in the middle of a cell", x, y)
+				}
+			case x%2 == 0 && y%2 == 0 || x == 0 || y == 0 || x == w-1 || y == h-1:
+				if open {
+					return nil, fmt.Errorf("pixel %d,%d is a corner or the border, it can't be open", x, y)
+				}
+			case open && x%2 == 0:
+				e.link(m.cellAt(x/2-1, y/2), m.cellAt(x/2, y/2))
+			case open:
+				e.link(m.cellAt(x/2, y/2-1), m.cellAt(x/2, y/2))
+			}
+		}
+	}
+	return e, nil
+}
+
+// the pixels of the same maze, a wall around every cell
+// and between the cells without a passage
+func (e *edgeGrid) blocks() maze {
+	m := maze{width: 2*e.width + 1, height: 2*e.height + 1, mask: e.mask}
+	m.cells = make([]byte, m.width*m.height)
+	for y := 0; y < m.height; y++ {
+		for x := 0; x < m.width; x++ {
+			if e.wall(x, y) || x%2 == 0 && y%2 == 0 {
+				m.set(x, y, 1)
+			}
+		}
+	}
+	for _, c := range e.activeCells() {
+		x, y := 2*(c%e.width)+1, 2*(c/e.width)+1
+		m.set(x, y, 0)
+	}
+	return m
+}
+
+// true if there's a wall on the pixel of the block model between two cells,
+// or on the border. Cells and corners are never walls: the corners are
+// drawn joining the walls around them
+func (e *edgeGrid) wall(x, y int) bool {
+	if x < 0 || y < 0 || x > 2*e.width || y > 2*e.height || x%2 == y%2 {
+		return false
+	}
+	// the cells on the two sides of the wall, when they exist
+	var cells []int
+	if x%2 == 0 {
+		for _, cx := range []int{x/2 - 1, x / 2} {
+			if cx >= 0 && cx < e.width {
+				cells = append(cells, y/2*e.width+cx)
+			}
+		}
+	} else {
+		for _, cy := range []int{y/2 - 1, y / 2} {
+			if cy >= 0 && cy < e.height {
+				cells = append(cells, cy*e.width+x/2)
+			}
+		}
+	}
+	inside := false
+	for _, c := range cells {
+		inside = inside || e.active(c)
+	}
+	return inside && (len(cells) == 1 || !e.linked(cells[0], cells[1]))
+}
+
+// the walls drawn with thin box lines, one char per pixel of the
+// block model: the corners join the walls reaching them
+func (e *edgeGrid) thinWalls() string {
+	var sb strings.Builder
+	for y := 0; y <= 2*e.height; y++ {
+		for x := 0; x <= 2*e.width; x++ {
+			switch {
+			case x%2 == 0 && y%2 == 0:
+				bits := 0
+				for i, d := range directions {
+					if e.wall(x+d.x, y+d.y) {
+						bits |= 1 << i
+					}
+				}
+				sb.WriteRune(passageGlyphs[bits])
+			case !e.wall(x, y):
+				sb.WriteByte(' ')
+			case x%2 == 0:
+				sb.WriteRune('│')
+			default:
+				sb.WriteRune('─')
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
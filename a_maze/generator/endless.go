@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// a maze that never ends, scrolling up the terminal. Eller's algorithm
+// only needs the sets of the current row, so a new row can be carved
+// whenever one leaves the screen: memory stays the same forever.
+// The last row is never reached, so sets only join at random, and every
+// set keeps a passage down or its cells would be closed off for good
+
+const scrollDelay = 100 * time.Millisecond
+
+type endless struct {
+	view    maze    // the pixels on screen, the bottom row is the newest
+	opts    options // only the seed is used, the grid is always rect
+	rng     *rand.Rand
+	cols    []int // the cells of a row, 0 to width/2-1
+	sets    []int // the set of each cell in the row being carved, 0 for none
+	nextSet int
+	pending [][]byte // pixel rows already carved but not on screen yet
+	rows    int      // pixel rows scrolled so far
+	paused  bool
+	delay   time.Duration
+	frame   int // id of the running timer, older frames are ignored
+}
+
+func newEndless(opts options) endless {
+	e := endless{opts: opts, delay: scrollDelay}
+	e.restart(21, 20)
+	return e
+}
+
+// a w x h pixels screen, filled by the first rows of the maze
+func (e *endless) restart(w, h int) {
+	e.view = maze{cells: filled(w * h), width: w, height: h, opts: e.opts}
+	e.rng = rand.New(rand.NewSource(e.opts.seed))
+	e.cols = make([]int, (w-1)/2)
+	for c := range e.cols {
+		e.cols[c] = c
+	}
+	e.sets = make([]int, len(e.cols))
+	e.nextSet = 1
+	// the border on top of the first row
+	e.pending = [][]byte{filled(w)}
+	e.rows = 0
+	for range h {
+		e.scroll()
+	}
+}
+
+// n pixels, all walls
+func filled(n int) []byte {
+	row := make([]byte, n)
+	for i := range row {
+		row[i] = 1
+	}
+	return row
+}
+
+// moves the maze up by a pixel row
+func (e *endless) scroll() {
+	if len(e.pending) == 0 {
+		e.carveRow()
+	}
+	w := e.view.width
+	copy(e.view.cells, e.view.cells[w:])
+	copy(e.view.cells[len(e.view.cells)-w:], e.pending[0])
+	e.pending = e.pending[1:]
+	e.rows++
+}
+
+// carves the next row of cells and the passages down from it
+func (e *endless) carveRow() {
+	cells, below := filled(e.view.width), filled(e.view.width)
+	for c := range e.cols {
+		if e.sets[c] == 0 {
+			e.sets[c] = e.nextSet
+			e.nextSet++
+		}
+		cells[2*c+1] = 0
+	}
+	// randomly join adjacent cells
+	for c := 0; c < len(e.cols)-1; c++ {
+		a, b := e.sets[c], e.sets[c+1]
+		if a == b || e.rng.Intn(2) == 0 {
+			continue
+		}
+		cells[2*c+2] = 0
+		for i, s := range e.sets {
+			if s == b {
+				e.sets[i] = a
+			}
+		}
+	}
+	// every set carves at least one passage down, the rest start a new set
+	down := make([]bool, len(e.cols))
+	for _, members := range groupBySet(e.cols, e.sets) {
+		e.rng.Shuffle(len(members), func(i, j int) { members[i], members[j] = members[j], members[i] })
+		for _, c := range members[:1+e.rng.Intn(len(members))] {
+			down[c] = true
+			below[2*c+1] = 0
+		}
+	}
+	for c, d := range down {
+		if !d {
+			e.sets[c] = 0
+		}
+	}
+	e.pending = append(e.pending, cells, below)
+}
+
+func (e endless) tick() tea.Cmd {
+	id := e.frame
+	return tea.Tick(e.delay, func(_ time.Time) tea.Msg {
+		return frameMsg{id}
+	})
+}
+
+func (e endless) Init() tea.Cmd {
+	return e.tick()
+}
+
+func (e endless) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			return e, tea.Quit
+		case " ":
+			e.paused = !e.paused
+		case "+":
+			e.delay = max(e.delay/2, minDelay)
+		case "-":
+			e.delay = min(e.delay*2, maxDelay)
+		default:
+			return e, nil
+		}
+		// restart the timer, so changes take effect at once
+		e.frame++
+		if e.paused {
+			return e, nil
+		}
+		return e, e.tick()
+	case tea.WindowSizeMsg:
+		e.restart(max(msg.Width, 3), max((msg.Height-1)*2, 2))
+		e.frame++
+		return e, e.tick()
+	case frameMsg:
+		if msg.id != e.frame || e.paused {
+			return e, nil
+		}
+		e.scroll()
+		return e, e.tick()
+	}
+	return e, nil
+}
+
+func (e endless) View() string {
+	state := fmt.Sprintf("%v", e.delay)
+	if e.paused {
+		state = "paused"
+	}
+	return fmt.Sprintf("%s\n%s - row %d %s - space pause, +/- speed, q quit",
+		baseStyle.Render(e.view.toString(halfBlocks{}, e.view.whole())),
+		e.status(), e.rows, state)
+}
+
+// a line with the information needed to scroll the same maze again
+func (e endless) status() string {
+	return fmt.Sprintf("eller endless %d wide seed %d", e.view.width, e.opts.seed)
+}
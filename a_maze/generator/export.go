@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// all the supported export formats:
+// blocks = the same half-block text shown on screen, and the only format
+// for weave mazes: box lines showing the passages crossing under
+// sextant, braille = text with more pixels per char, see renderer.go
+// ascii  = one char per pixel, '#' for walls and ' ' for passages
+// thin   = thin walls drawn with box lines, one char per pixel, only for the rect grid
+// json   = width, height and the raw cells
+// svg, png = images, each pixel becomes a scale x scale square
+// and the area outside of the mask is left white
+var exportFormats = []string{"blocks", "sextant", "braille", "ascii", "thin", "json", "svg", "png"}
+
+// image pixels for each maze pixel, when not given
+const defaultScale = 10
+
+// guesses the export format from the file name
+func formatFromName(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".txt":
+		return "ascii"
+	case ".json":
+		return "json"
+	case ".svg":
+		return "svg"
+	case ".png":
+		return "png"
+	}
+	return ""
+}
+
+// the JSON representation of a maze
+type mazeJSON struct {
+	Width  int     `json:"width"`
+	Height int     `json:"height"`
+	Algo   string  `json:"algo,omitempty"`
+	Seed   int64   `json:"seed,omitempty"`
+	Braid  float64 `json:"braid,omitempty"`
+	Cells  []int   `json:"cells"` // row by row, 1 = wall
+}
+
+// writes the maze to a file, "-" means standard output
+func (m maze) exportFile(name, format string, scale int) error {
+	if err := m.exportable(format); err != nil {
+		return err
+	}
+	return writeFile(name, func(w io.Writer) error {
+		return m.export(w, format, scale)
+	})
+}
+
+// the other formats would draw a crossing of a weave maze as a junction,
+// making a different and easier maze
+func (m maze) exportable(format string) error {
+	if m.under != nil && format != "blocks" && slices.Contains(exportFormats, format) {
+		return fmt.Errorf("weave mazes can only be exported as blocks, %s can't show the passages crossing under", format)
+	}
+	return nil
+}
+
+func (m maze) export(w io.Writer, format string, scale int) error {
+	if err := m.exportable(format); err != nil {
+		return err
+	}
+	switch format {
+	case "blocks", "sextant", "braille":
+		if format == "blocks" && m.under != nil {
+			_, err := fmt.Fprintln(w, m.passages(m.whole(), nil))
+			return err
+		}
+		wall := func(x, y int) bool { return m.get(x, y) == 1 && m.visible(x, y) }
+		return writeText(w, m.width, m.height, wall, textRenderers[format])
+	case "ascii":
+		return writeText(w, m.width, m.height, func(x, y int) bool { return m.get(x, y) == 1 }, asciiChars{})
+	case "thin":
+		e, err := m.edges()
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, e.thinWalls())
+		return err
+	case "json":
+		j := mazeJSON{Width: m.width, Height: m.height, Algo: m.opts.algo, Seed: m.opts.seed, Braid: m.opts.braid, Cells: make([]int, len(m.cells))}
+		for i, c := range m.cells {
+			j.Cells[i] = int(c)
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(j)
+	case "svg":
+		_, err := io.WriteString(w, m.toSVG(scale))
+		return err
+	case "png":
+		return png.Encode(w, m.toImage(scale))
+	}
+	return fmt.Errorf("unknown export format %q, choose one of: %s", format, strings.Join(exportFormats, ", "))
+}
+
+// black walls on white background, good for printing.
+// Horizontal runs of wall pixels are merged into a single rect,
+// shapes draw their walls as lines and arcs
+func (m maze) toSVG(scale int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n",
+		m.width*scale, m.height*scale, m.width*scale, m.height*scale)
+	sb.WriteString("<rect width=\"100%\" height=\"100%\" fill=\"white\"/>\n")
+	if m.shape != nil {
+		m.shape.svgWalls(&sb, scale)
+		sb.WriteString("</svg>\n")
+		return sb.String()
+	}
+	for y := 0; y < m.height; y++ {
+		for x := 0; x < m.width; x++ {
+			if !m.wallShown(x, y) {
+				continue
+			}
+			start := x
+			for x+1 < m.width && m.wallShown(x+1, y) {
+				x++
+			}
+			fmt.Fprintf(&sb, "<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"black\"/>\n",
+				start*scale, y*scale, (x-start+1)*scale, scale)
+		}
+	}
+	sb.WriteString("</svg>\n")
+	return sb.String()
+}
+
+// true for the walls drawn in the images
+func (m maze) wallShown(x, y int) bool {
+	return m.get(x, y) == 1 && m.visible(x, y)
+}
+
+// black walls on white background, same as the SVG
+func (m maze) toImage(scale int) image.Image {
+	palette := color.Palette{color.White, color.Black}
+	img := image.NewPaletted(image.Rect(0, 0, m.width*scale, m.height*scale), palette)
+	for y := 0; y < m.height*scale; y++ {
+		for x := 0; x < m.width*scale; x++ {
+			if m.wallShown(x/scale, y/scale) {
+				img.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+	return img
+}
@@ -0,0 +1,404 @@
+package main
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// a Generator carves passages into a maze that starts completely filled.
+// Implementations only work with cells and links (see grid.go) and never
+// touch the wall pixels directly, so they work on every topology.
+// All the randomness must come from rng, so the same seed always gives
+// the same maze
+type Generator interface {
+	Generate(g grid, rng *rand.Rand)
+}
+
+// all the available algorithms, selectable by name from the command line
+var generators = map[string]Generator{
+	"binarytree":   binaryTree{},
+	"backtracker":  backtracker{},
+	"prim":         prim{},
+	"kruskal":      kruskal{},
+	"wilson":       wilson{},
+	"aldousbroder": aldousBroder{},
+	"eller":        eller{},
+	"growingtree":  growingTree{},
+}
+
+// returns the sorted list of generator names
+func generatorNames() []string {
+	names := make([]string, 0, len(generators))
+	for name := range generators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// the original algorithm: every cell flips a coin and carves either
+// east or south, on other grids towards any neighbour coming after it
+type binaryTree struct{}
+
+func (binaryTree) Generate(g grid, rng *rand.Rand) {
+	for _, c := range g.activeCells() {
+		var candidates []int
+		for _, n := range g.neighbours(c) {
+			if n > c {
+				candidates = append(candidates, n)
+			}
+		}
+		sort.Ints(candidates)
+		if len(candidates) > 0 {
+			g.link(c, candidates[rng.Intn(len(candidates))])
+		}
+	}
+	// with a mask or on some grids a few cells have no way forward
+	connectRegions(g, rng)
+}
+
+// depth-first search: walk randomly until stuck, then backtrack
+type backtracker struct{}
+
+func (backtracker) Generate(g grid, rng *rand.Rand) {
+	active := g.activeCells()
+	if len(active) == 0 {
+		return
+	}
+	visited := make([]bool, g.cellCount())
+	start := active[rng.Intn(len(active))]
+	visited[start] = true
+	stack := []int{start}
+	for len(stack) > 0 {
+		c := stack[len(stack)-1]
+		next := unvisited(g.neighbours(c), visited)
+		if len(next) == 0 {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		n := next[rng.Intn(len(next))]
+		g.link(c, n)
+		visited[n] = true
+		stack = append(stack, n)
+	}
+}
+
+// randomized Prim: grow the maze from a random frontier cell
+type prim struct{}
+
+func (prim) Generate(g grid, rng *rand.Rand) {
+	active := g.activeCells()
+	if len(active) == 0 {
+		return
+	}
+	inMaze := make([]bool, g.cellCount())
+	inFrontier := make([]bool, g.cellCount())
+	var frontier []int
+	add := func(c int) {
+		inMaze[c] = true
+		for _, n := range g.neighbours(c) {
+			if !inMaze[n] && !inFrontier[n] {
+				inFrontier[n] = true
+				frontier = append(frontier, n)
+			}
+		}
+	}
+	add(active[rng.Intn(len(active))])
+	for len(frontier) > 0 {
+		i := rng.Intn(len(frontier))
+		c := frontier[i]
+		frontier[i] = frontier[len(frontier)-1]
+		frontier = frontier[:len(frontier)-1]
+		var in []int
+		for _, n := range g.neighbours(c) {
+			if inMaze[n] {
+				in = append(in, n)
+			}
+		}
+		g.link(c, in[rng.Intn(len(in))])
+		add(c)
+	}
+}
+
+// randomized Kruskal: join random walls between cells of different sets
+type kruskal struct{}
+
+func (kruskal) Generate(g grid, rng *rand.Rand) {
+	type edge struct{ a, b int }
+	var edges []edge
+	for _, c := range g.activeCells() {
+		for _, n := range g.neighbours(c) {
+			if c < n {
+				edges = append(edges, edge{c, n})
+			}
+		}
+	}
+	rng.Shuffle(len(edges), func(i, j int) { edges[i], edges[j] = edges[j], edges[i] })
+	sets := newDisjointSet(g.cellCount())
+	for _, e := range edges {
+		if sets.union(e.a, e.b) {
+			g.link(e.a, e.b)
+		}
+	}
+}
+
+// Wilson: loop-erased random walks until every cell joins the maze.
+// Unbiased like Aldous-Broder, but much faster at the end
+type wilson struct{}
+
+func (wilson) Generate(g grid, rng *rand.Rand) {
+	active := g.activeCells()
+	if len(active) == 0 {
+		return
+	}
+	inMaze := make([]bool, g.cellCount())
+	inMaze[active[rng.Intn(len(active))]] = true
+	remaining := len(active) - 1
+	// position of each cell in the current walk, -1 if not on it
+	onPath := make([]int, g.cellCount())
+	for i := range onPath {
+		onPath[i] = -1
+	}
+	for remaining > 0 {
+		var start int
+		for {
+			start = active[rng.Intn(len(active))]
+			if !inMaze[start] {
+				break
+			}
+		}
+		path := []int{start}
+		onPath[start] = 0
+		for c := start; !inMaze[c]; {
+			nb := g.neighbours(c)
+			c = nb[rng.Intn(len(nb))]
+			if i := onPath[c]; i >= 0 {
+				// erase the loop
+				for _, p := range path[i+1:] {
+					onPath[p] = -1
+				}
+				path = path[:i+1]
+			} else {
+				onPath[c] = len(path)
+				path = append(path, c)
+			}
+		}
+		for i := 0; i < len(path)-1; i++ {
+			g.link(path[i], path[i+1])
+			inMaze[path[i]] = true
+			remaining--
+		}
+		for _, p := range path {
+			onPath[p] = -1
+		}
+	}
+}
+
+// Aldous-Broder: a plain random walk, linking each cell on first visit
+type aldousBroder struct{}
+
+func (aldousBroder) Generate(g grid, rng *rand.Rand) {
+	active := g.activeCells()
+	if len(active) == 0 {
+		return
+	}
+	visited := make([]bool, g.cellCount())
+	c := active[rng.Intn(len(active))]
+	visited[c] = true
+	for remaining := len(active) - 1; remaining > 0; {
+		nb := g.neighbours(c)
+		n := nb[rng.Intn(len(nb))]
+		if !visited[n] {
+			g.link(c, n)
+			visited[n] = true
+			remaining--
+		}
+		c = n
+	}
+}
+
+// Eller: works one row at a time, keeping track of which cells
+// of the current row are already connected.
+// Cells outside of the mask belong to set 0
+type eller struct{}
+
+func (eller) Generate(g grid, rng *rand.Rand) {
+	rows := g.cellRows()
+	sets := make([]int, g.cellCount())
+	nextSet := 1
+	for r, row := range rows {
+		for _, c := range row {
+			if !g.active(c) {
+				sets[c] = 0
+			} else if sets[c] == 0 {
+				sets[c] = nextSet
+				nextSet++
+			}
+		}
+		last := r == len(rows)-1
+		// randomly join adjacent cells, always on the last row
+		for i := 0; i < len(row)-1; i++ {
+			a, b := row[i], row[i+1]
+			if sets[a] == 0 || sets[b] == 0 || sets[a] == sets[b] || (!last && rng.Intn(2) == 0) {
+				continue
+			}
+			g.link(a, b)
+			old := sets[b]
+			for _, c := range row {
+				if sets[c] == old {
+					sets[c] = sets[a]
+				}
+			}
+		}
+		if last {
+			break
+		}
+		below := map[int]bool{}
+		for _, c := range rows[r+1] {
+			below[c] = true
+		}
+		// every set must carve at least one passage down
+		for _, members := range groupBySet(row, sets) {
+			type edge struct{ a, b int }
+			var down []edge
+			for _, c := range members {
+				for _, n := range g.neighbours(c) {
+					if sets[c] != 0 && below[n] {
+						down = append(down, edge{c, n})
+					}
+				}
+			}
+			if len(down) == 0 {
+				continue
+			}
+			rng.Shuffle(len(down), func(i, j int) { down[i], down[j] = down[j], down[i] })
+			for _, e := range down[:1+rng.Intn(len(down))] {
+				// on some grids two cells share a neighbour below
+				if sets[e.b] != 0 {
+					continue
+				}
+				g.link(e.a, e.b)
+				sets[e.b] = sets[e.a]
+			}
+		}
+	}
+	// with a mask a set may have no way down
+	connectRegions(g, rng)
+}
+
+// returns the cells of a row belonging to each set, in order of first appearance
+func groupBySet(row []int, sets []int) [][]int {
+	index := map[int]int{}
+	var groups [][]int
+	for _, c := range row {
+		s := sets[c]
+		i, ok := index[s]
+		if !ok {
+			i = len(groups)
+			index[s] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], c)
+	}
+	return groups
+}
+
+// growing tree: like the backtracker, but half of the time
+// it continues from a random cell instead of the newest one
+type growingTree struct{}
+
+func (growingTree) Generate(g grid, rng *rand.Rand) {
+	cells := g.activeCells()
+	if len(cells) == 0 {
+		return
+	}
+	visited := make([]bool, g.cellCount())
+	start := cells[rng.Intn(len(cells))]
+	visited[start] = true
+	active := []int{start}
+	for len(active) > 0 {
+		i := len(active) - 1
+		if rng.Intn(2) == 0 {
+			i = rng.Intn(len(active))
+		}
+		c := active[i]
+		next := unvisited(g.neighbours(c), visited)
+		if len(next) == 0 {
+			active = append(active[:i], active[i+1:]...)
+			continue
+		}
+		n := next[rng.Intn(len(next))]
+		g.link(c, n)
+		visited[n] = true
+		active = append(active, n)
+	}
+}
+
+// joins the parts of the maze that are not connected yet,
+// opening walls at random like Kruskal does
+func connectRegions(g grid, rng *rand.Rand) {
+	sets := newDisjointSet(g.cellCount())
+	type edge struct{ a, b int }
+	var closed []edge
+	parts := len(g.activeCells())
+	for _, c := range g.activeCells() {
+		for _, n := range g.neighbours(c) {
+			if c < n && g.linked(c, n) {
+				if sets.union(c, n) {
+					parts--
+				}
+			} else if c < n {
+				closed = append(closed, edge{c, n})
+			}
+		}
+	}
+	// nothing to do, and the random source is left untouched
+	if parts <= 1 {
+		return
+	}
+	rng.Shuffle(len(closed), func(i, j int) { closed[i], closed[j] = closed[j], closed[i] })
+	for _, e := range closed {
+		if sets.union(e.a, e.b) {
+			g.link(e.a, e.b)
+		}
+	}
+}
+
+func unvisited(cells []int, visited []bool) []int {
+	var result []int
+	for _, c := range cells {
+		if !visited[c] {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// union-find over cell indexes, used by Kruskal
+type disjointSet []int
+
+func newDisjointSet(n int) disjointSet {
+	d := make(disjointSet, n)
+	for i := range d {
+		d[i] = i
+	}
+	return d
+}
+
+func (d disjointSet) find(i int) int {
+	for d[i] != i {
+		d[i] = d[d[i]]
+		i = d[i]
+	}
+	return i
+}
+
+// joins the sets of a and b, returns false if they were already joined
+func (d disjointSet) union(a, b int) bool {
+	ra, rb := d.find(a), d.find(b)
+	if ra == rb {
+		return false
+	}
+	d[rb] = ra
+	return true
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"io"
+	"math"
+)
+
+// a grid is the layout of the cells of a maze and of the passages between them.
+// Generators and statistics only use these methods, never coordinates,
+// so they work on every topology
+type grid interface {
+	cellCount() int
+	// true if the cell is part of the maze
+	active(c int) bool
+	activeCells() []int
+	// the adjacent active cells
+	neighbours(c int) []int
+	// opens the passage between two adjacent cells
+	link(a, b int)
+	// true if there's a passage between two adjacent cells
+	linked(a, b int) bool
+	// the cells row by row, for the algorithms working a row at a time.
+	// Consecutive cells of a row are adjacent
+	cellRows() [][]int
+}
+
+// the rectangular grid is the maze itself, see cells.go.
+// The other topologies are shapes: their cells have their own geometry
+// and are then drawn on the maze pixels, so renderers, solvers and
+// image exports keep working with walls and passages
+type shape interface {
+	grid
+	// the cell covering a pixel, -1 outside of the grid
+	cellAtPixel(x, y int) int
+	// the pixel at the center of a cell
+	center(c int) (int, int)
+	// writes the walls as svg elements, each pixel is scale x scale
+	svgWalls(w io.Writer, scale int)
+}
+
+// all the available topologies, selectable by name from the command line.
+// Each shape fills a w x h pixels area with as many cells as fit,
+// weave is the rectangle with passages crossing under each other
+var gridNames = []string{"rect", "weave", "hex", "triangle", "polar"}
+
+var shapes = map[string]func(w, h int) shape{
+	"hex":      newHexGrid,
+	"triangle": newTriangleGrid,
+	"polar":    newPolarGrid,
+}
+
+// the cells of a w x h pixels area, nil for the rectangle
+func newShape(name string, w, h int) shape {
+	if f, ok := shapes[name]; ok {
+		return f(w, h)
+	}
+	return nil
+}
+
+// the grid used by the generators
+func (m *maze) grid() grid {
+	if m.shape != nil {
+		return m.shape
+	}
+	if m.under != nil {
+		return weave{m}
+	}
+	return m
+}
+
+// the pixel at the center of a cell, on any grid
+func (m *maze) center(c int) (int, int) {
+	if m.shape != nil {
+		return m.shape.center(c)
+	}
+	return m.pixelXY(c)
+}
+
+// draws the walls of the shape on the pixels: a pixel is a wall when it
+// borders another cell without a passage to its own. Only the cell with
+// the lower index gets the wall, so walls are a single pixel thick.
+// Pixels outside of the shape are filled, and hidden by visible()
+func (m *maze) draw() {
+	for y := 0; y < m.height; y++ {
+		for x := 0; x < m.width; x++ {
+			c := m.shape.cellAtPixel(x, y)
+			wall := c < 0
+			for _, d := range directions {
+				if wall {
+					break
+				}
+				n := m.shape.cellAtPixel(x+d.x, y+d.y)
+				wall = n < 0 || (n > c && !m.shape.linked(c, n))
+			}
+			if wall {
+				m.set(x, y, 1)
+			} else {
+				m.set(x, y, 0)
+			}
+		}
+	}
+}
+
+// records every link, for the animation
+type recorder struct {
+	grid
+	steps *[]step
+}
+
+func (r recorder) link(a, b int) {
+	r.grid.link(a, b)
+	*r.steps = append(*r.steps, step{a, b})
+}
+
+// the cells and the passages of a shape, with the cell covering each pixel
+type shapeCells struct {
+	width  int
+	height int
+	owner  []int   // cell of each pixel, -1 outside of the grid
+	links  [][]int // the cells linked to each cell
+}
+
+// locate returns the cell covering a point, pixels are sampled at their center
+func newShapeCells(w, h, cells int, locate func(x, y float64) int) shapeCells {
+	s := shapeCells{width: w, height: h, owner: make([]int, w*h), links: make([][]int, cells)}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			s.owner[y*w+x] = locate(float64(x)+0.5, float64(y)+0.5)
+		}
+	}
+	return s
+}
+
+func (s *shapeCells) cellCount() int {
+	return len(s.links)
+}
+
+// shapes have no mask, every cell is part of the maze
+func (s *shapeCells) active(c int) bool {
+	return true
+}
+
+func (s *shapeCells) activeCells() []int {
+	result := make([]int, s.cellCount())
+	for c := range result {
+		result[c] = c
+	}
+	return result
+}
+
+func (s *shapeCells) link(a, b int) {
+	if s.linked(a, b) {
+		return
+	}
+	s.links[a] = append(s.links[a], b)
+	s.links[b] = append(s.links[b], a)
+}
+
+func (s *shapeCells) linked(a, b int) bool {
+	for _, n := range s.links[a] {
+		if n == b {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *shapeCells) cellAtPixel(x, y int) int {
+	if x < 0 || y < 0 || x >= s.width || y >= s.height {
+		return -1
+	}
+	return s.owner[y*s.width+x]
+}
+
+// the cells of a shape are numbered row by row
+func rowsOf(counts []int) [][]int {
+	rows := make([][]int, len(counts))
+	c := 0
+	for r, n := range counts {
+		for i := 0; i < n; i++ {
+			rows[r] = append(rows[r], c)
+			c++
+		}
+	}
+	return rows
+}
+
+// the neighbours that exist, in the given order
+func existing(cells ...int) []int {
+	result := make([]int, 0, len(cells))
+	for _, c := range cells {
+		if c >= 0 {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+var sqrt3 = math.Sqrt(3)
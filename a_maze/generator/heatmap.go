@@ -0,0 +1,142 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// a heatmap colors every open pixel by its distance from a source pixel:
+// long corridors and the areas a generator tends to avoid stand out at once.
+// Like the solvers it works on the pixels, so every maze can have one
+
+// SUSE Jungle green next to the source, Mint in the middle
+// and Persimmon on the farthest pixels
+var heatStops = []struct{ r, g, b float64 }{{0x30, 0xba, 0x78}, {0x90, 0xeb, 0xcd}, {0xfe, 0x7c, 0x3f}}
+
+// the same gradient for terminals with only 16 colors:
+// green, bright green, bright yellow, bright red, red
+var heatANSI = []string{"2", "10", "11", "9", "1"}
+
+type heatmap struct {
+	spec  string // where the source is: start, center or x,y
+	from  int    // the source pixel
+	width int    // of the maze, to tell the coordinates of the pixels
+	dist  []int  // distance of every pixel from the source, -1 when unreachable
+	max   int    // the farthest distance
+}
+
+// true for the places the source of a heatmap can be given with
+func validHeatSource(spec string) bool {
+	_, _, err := parseXY(spec)
+	return spec == "start" || spec == "center" || err == nil
+}
+
+func parseXY(spec string) (int, int, error) {
+	xs, ys, ok := strings.Cut(spec, ",")
+	if !ok {
+		return 0, 0, fmt.Errorf("%q is not x,y", spec)
+	}
+	x, err := strconv.Atoi(xs)
+	if err != nil {
+		return 0, 0, err
+	}
+	y, err := strconv.Atoi(ys)
+	return x, y, err
+}
+
+// the heatmap from the source in spec, the open pixel nearest to the
+// one asked, so it keeps working when the maze is resized
+func (m *maze) heatmap(spec string) heatmap {
+	h := heatmap{spec: spec, from: -1, width: m.width}
+	x, y, err := parseXY(spec)
+	switch {
+	case spec == "start":
+		from, _, _ := m.endpoints()
+		x, y = from%m.width, from/m.width
+	case spec == "center" || err != nil:
+		x, y = m.width/2, m.height/2
+	}
+	best := math.MaxInt
+	for p, c := range m.cells {
+		if d := abs(p%m.width-x) + abs(p/m.width-y); c == 0 && d < best {
+			h.from, best = p, d
+		}
+	}
+	if h.from >= 0 {
+		h.dist = m.distances(h.from)
+		for _, d := range h.dist {
+			h.max = max(h.max, d)
+		}
+	}
+	return h
+}
+
+// Dijkstra from a pixel: every move costs the pixels walked,
+// so going below a weave crossing costs two
+func (m *maze) distances(from int) []int {
+	dist := make([]int, len(m.cells))
+	for i := range dist {
+		dist[i] = -1
+	}
+	dist[from] = 0
+	open := &priorityQueue{{from, 0}}
+	for open.Len() > 0 {
+		item := heap.Pop(open).(queueItem)
+		p := item.pixel
+		if item.priority > dist[p] {
+			continue
+		}
+		for _, n := range m.openAround(p) {
+			d := dist[p] + abs(n%m.width-p%m.width) + abs(n/m.width-p/m.width)
+			if dist[n] < 0 || d < dist[n] {
+				dist[n] = d
+				heap.Push(open, queueItem{n, d})
+			}
+		}
+	}
+	return dist
+}
+
+// the color of the pixels at distance d, nil for the walls and the
+// unreachable ones. Only the pixels up to limit are colored
+func (h heatmap) color(p, limit int) lipgloss.TerminalColor {
+	d := h.dist[p]
+	if d < 0 || d > limit {
+		return nil
+	}
+	t := 0.0
+	if h.max > 0 {
+		t = float64(d) / float64(h.max)
+	}
+	return heatColor(t)
+}
+
+// the gradient at t between 0 and 1. lipgloss picks the variant
+// fitting the terminal: true color, 256 colors or the basic 16
+func heatColor(t float64) lipgloss.TerminalColor {
+	pos := t * float64(len(heatStops)-1)
+	i := min(int(pos), len(heatStops)-2)
+	f := pos - float64(i)
+	a, b := heatStops[i], heatStops[i+1]
+	r, g, bl := a.r+(b.r-a.r)*f, a.g+(b.g-a.g)*f, a.b+(b.b-a.b)*f
+	// the 6x6x6 cube of the 256 colors palette
+	cube := func(v float64) int { return int(math.Round(v / 255 * 5)) }
+	return lipgloss.CompleteColor{
+		TrueColor: fmt.Sprintf("#%02x%02x%02x", int(r), int(g), int(bl)),
+		ANSI256:   strconv.Itoa(16 + 36*cube(r) + 6*cube(g) + cube(bl)),
+		ANSI:      heatANSI[min(int(t*float64(len(heatANSI))), len(heatANSI)-1)],
+	}
+}
+
+// a short description for the status line
+func (h heatmap) String() string {
+	if h.from < 0 {
+		return "heatmap: no open pixel"
+	}
+	return fmt.Sprintf("heatmap from %d,%d, farthest %d", h.from%h.width, h.from/h.width, h.max)
+}
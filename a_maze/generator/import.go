@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"image"
+	_ "image/png"
+	"io"
+	"os"
+	"strings"
+)
+
+// the formats that can be loaded, the counterpart of exportFormats
+var importFormats = []string{"ascii", "thin", "json", "png"}
+
+// smallest maze that makes sense: a border around a single cell
+const minSize = 3
+
+// reads a maze from a file, "-" means standard input
+func importFile(name, format string) (maze, error) {
+	if name == "-" {
+		return importMaze(os.Stdin, format)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return maze{}, err
+	}
+	defer f.Close()
+	m, err := importMaze(f, format)
+	if err != nil {
+		return maze{}, fmt.Errorf("%s: %w", name, err)
+	}
+	return m, nil
+}
+
+func importMaze(r io.Reader, format string) (maze, error) {
+	var m maze
+	var err error
+	switch format {
+	case "ascii":
+		m, err = readASCII(r)
+	case "thin":
+		m, err = readThin(r)
+	case "json":
+		m, err = readJSON(r)
+	case "png":
+		m, err = readImage(r)
+	default:
+		return maze{}, fmt.Errorf("unknown import format %q, choose one of: %s", format, strings.Join(importFormats, ", "))
+	}
+	if err != nil {
+		return maze{}, err
+	}
+	if m.width < minSize || m.height < minSize {
+		return maze{}, fmt.Errorf("maze is %dx%d, must be at least %dx%d", m.width, m.height, minSize, minSize)
+	}
+	return m, nil
+}
+
+// '#' is a wall, ' ' is a passage, every line must have the same length.
+// Empty lines at the end are ignored
+func readASCII(r io.Reader) (maze, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimRight(scanner.Text(), "\r"))
+	}
+	if err := scanner.Err(); err != nil {
+		return maze{}, err
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return maze{}, fmt.Errorf("empty maze")
+	}
+	m := maze{width: len(lines[0]), height: len(lines)}
+	m.cells = make([]byte, m.width*m.height)
+	for y, line := range lines {
+		if len(line) != m.width {
+			return maze{}, fmt.Errorf("line %d is %d chars long, expected %d", y+1, len(line), m.width)
+		}
+		for x, ch := range []byte(line) {
+			switch ch {
+			case '#':
+				m.set(x, y, 1)
+			case ' ':
+			default:
+				return maze{}, fmt.Errorf("line %d, column %d: unexpected %q, only '#' and ' ' are allowed", y+1, x+1, ch)
+			}
+		}
+	}
+	return m, nil
+}
+
+// the box lines written by export: every char but ' ' is a wall.
+// The walls must enclose cells like in the generated mazes, a corner
+// with no walls around it is written as ' ' but it's still a wall
+func readThin(r io.Reader) (maze, error) {
+	var lines [][]rune
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lines = append(lines, []rune(strings.TrimRight(scanner.Text(), "\r")))
+	}
+	if err := scanner.Err(); err != nil {
+		return maze{}, err
+	}
+	for len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return maze{}, fmt.Errorf("empty maze")
+	}
+	m := maze{width: len(lines[0]), height: len(lines)}
+	m.cells = make([]byte, m.width*m.height)
+	for y, line := range lines {
+		if len(line) != m.width {
+			return maze{}, fmt.Errorf("line %d is %d chars long, expected %d", y+1, len(line), m.width)
+		}
+		for x, ch := range line {
+			if ch != ' ' || (x%2 == 0 && y%2 == 0) {
+				m.set(x, y, 1)
+			}
+		}
+	}
+	e, err := m.edges()
+	if err != nil {
+		return maze{}, err
+	}
+	return e.blocks(), nil
+}
+
+// the same document written by export
+func readJSON(r io.Reader) (maze, error) {
+	var j mazeJSON
+	if err := json.NewDecoder(r).Decode(&j); err != nil {
+		return maze{}, err
+	}
+	if j.Width <= 0 || j.Height <= 0 {
+		return maze{}, fmt.Errorf("invalid size %dx%d", j.Width, j.Height)
+	}
+	if len(j.Cells) != j.Width*j.Height {
+		return maze{}, fmt.Errorf("%d cells for a %dx%d maze, expected %d", len(j.Cells), j.Width, j.Height, j.Width*j.Height)
+	}
+	m := maze{width: j.Width, height: j.Height, opts: options{algo: j.Algo, seed: j.Seed, braid: j.Braid}, cells: make([]byte, len(j.Cells))}
+	for i, c := range j.Cells {
+		if c != 0 && c != 1 {
+			return maze{}, fmt.Errorf("cell %d has value %d, only 0 and 1 are allowed", i, c)
+		}
+		m.cells[i] = byte(c)
+	}
+	return m, nil
+}
+
+// dark pixels are walls, light pixels are passages.
+// Images written by export are scaled up, so the pixel size
+// is detected and every square becomes a single maze pixel
+func readImage(r io.Reader) (maze, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return maze{}, err
+	}
+	b := img.Bounds()
+	walls := make([]byte, b.Dx()*b.Dy())
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			if isDark(img, b.Min.X+x, b.Min.Y+y) {
+				walls[y*b.Dx()+x] = 1
+			}
+		}
+	}
+	scale := pixelSize(walls, b.Dx(), b.Dy())
+	m := maze{width: b.Dx() / scale, height: b.Dy() / scale}
+	m.cells = make([]byte, m.width*m.height)
+	for y := 0; y < m.height; y++ {
+		for x := 0; x < m.width; x++ {
+			m.set(x, y, walls[y*scale*b.Dx()+x*scale])
+		}
+	}
+	return m, nil
+}
+
+// true for pixels darker than middle gray, transparent pixels are light
+func isDark(img image.Image, x, y int) bool {
+	r, g, b, a := img.At(x, y).RGBA()
+	if a < 0x8000 {
+		return false
+	}
+	// ITU-R 601 luma, values are 16 bit
+	return 299*r+587*g+114*b < 500*0xffff
+}
+
+// the size of the squares an image is made of:
+// the greatest common divisor of the image size and of all the runs
+// of equal pixels, both horizontal and vertical
+func pixelSize(walls []byte, w, h int) int {
+	size := gcd(w, h)
+	for y := 0; y < h && size > 1; y++ {
+		run := 1
+		for x := 1; x < w; x++ {
+			if walls[y*w+x] == walls[y*w+x-1] {
+				run++
+				continue
+			}
+			size = gcd(size, run)
+			run = 1
+		}
+	}
+	for x := 0; x < w && size > 1; x++ {
+		run := 1
+		for y := 1; y < h; y++ {
+			if walls[y*w+x] == walls[(y-1)*w+x] {
+				run++
+				continue
+			}
+			size = gcd(size, run)
+			run = 1
+		}
+	}
+	return size
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"testing"
+)
+
+func TestThinRoundTrip(t *testing.T) {
+	for _, b := range []float64{0, 0.2, 1} {
+		m := NewMaze(21, 11, options{algo: "backtracker", seed: 7, braid: b})
+		var buf bytes.Buffer
+		if err := m.export(&buf, "thin", defaultScale); err != nil {
+			t.Fatalf("braid %g: export: %v", b, err)
+		}
+		got, err := importMaze(&buf, "thin")
+		if err != nil {
+			t.Fatalf("braid %g: import: %v", b, err)
+		}
+		if got.width != m.width || got.height != m.height || !slices.Equal(got.cells, m.cells) {
+			t.Errorf("braid %g: the imported maze differs from the exported one", b)
+		}
+	}
+}
+
+// only blocks show the crossings of a weave maze
+func TestWeaveExport(t *testing.T) {
+	m := NewMaze(21, 11, options{algo: "backtracker", seed: 1, grid: "weave"})
+	for _, format := range exportFormats {
+		err := m.export(io.Discard, format, defaultScale)
+		if format == "blocks" && err != nil {
+			t.Errorf("blocks: %v", err)
+		}
+		if format != "blocks" && err == nil {
+			t.Errorf("%s: expected an error for a weave maze", format)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// the key bindings of the interactive view, listed by the help footer
+type keyMap struct {
+	Regenerate key.Binding
+	NextSeed   key.Binding
+	PrevSeed   key.Binding
+	Up         key.Binding
+	Down       key.Binding
+	Left       key.Binding
+	Right      key.Binding
+	PageUp     key.Binding
+	PageDown   key.Binding
+	Algorithm  key.Binding
+	Solver     key.Binding
+	ZoomIn     key.Binding
+	ZoomOut    key.Binding
+	Export     key.Binding
+	Help       key.Binding
+	Quit       key.Binding
+}
+
+var keys = keyMap{
+	Regenerate: key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "new maze")),
+	NextSeed:   key.NewBinding(key.WithKeys("n"), key.WithHelp("n", "next seed")),
+	PrevSeed:   key.NewBinding(key.WithKeys("p"), key.WithHelp("p", "previous seed")),
+	Up:         key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", "pan up")),
+	Down:       key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "pan down")),
+	Left:       key.NewBinding(key.WithKeys("left", "h"), key.WithHelp("←/h", "pan left")),
+	Right:      key.NewBinding(key.WithKeys("right", "l"), key.WithHelp("→/l", "pan right")),
+	PageUp:     key.NewBinding(key.WithKeys("pgup"), key.WithHelp("pgup", "page up")),
+	PageDown:   key.NewBinding(key.WithKeys("pgdown"), key.WithHelp("pgdown", "page down")),
+	Algorithm:  key.NewBinding(key.WithKeys("a"), key.WithHelp("a", "algorithm")),
+	Solver:     key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "solver")),
+	ZoomIn:     key.NewBinding(key.WithKeys("+", "="), key.WithHelp("+", "zoom in")),
+	ZoomOut:    key.NewBinding(key.WithKeys("-"), key.WithHelp("-", "zoom out")),
+	Export:     key.NewBinding(key.WithKeys("e"), key.WithHelp("e", "export png")),
+	Help:       key.NewBinding(key.WithKeys("?"), key.WithHelp("?", "more keys")),
+	Quit:       key.NewBinding(key.WithKeys("q", "esc", "ctrl+c"), key.WithHelp("q", "quit")),
+}
+
+func (k keyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Regenerate, k.Algorithm, k.Solver, k.ZoomIn, k.ZoomOut, k.Help, k.Quit}
+}
+
+func (k keyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Regenerate, k.NextSeed, k.PrevSeed},
+		{k.Algorithm, k.Solver, k.Export},
+		{k.Up, k.Down, k.Left, k.Right},
+		{k.PageUp, k.PageDown, k.ZoomIn, k.ZoomOut},
+		{k.Help, k.Quit},
+	}
+}
+
+// the lines under the maze: status and help
+func (m maze) footerHeight() int {
+	return 2 + strings.Count(m.help.View(keys), "\n")
+}
+
+// the algorithm after the current one, only the ones working on this grid
+func nextAlgorithm(opts options) string {
+	names := generatorNames()
+	if opts.grid == "weave" {
+		names = weaveGenerators
+	}
+	i := slices.Index(names, opts.algo)
+	return names[(i+1)%len(names)]
+}
+
+// the solver after the current one, then none
+func nextSolver(name string) string {
+	names := solverNames()
+	i := slices.Index(names, name)
+	if i == len(names)-1 {
+		return ""
+	}
+	return names[i+1]
+}
+
+// the renderer with bigger (in) or smaller (out) pixels than the current one.
+// Weave mazes are always drawn with their passages, so they can't zoom
+func (m maze) zoomed(in bool) string {
+	if m.under != nil {
+		return m.renderer
+	}
+	i := slices.IndexFunc(rendererNames, func(name string) bool { return renderers[name] == m.chars() })
+	if in {
+		return rendererNames[max(i-1, 0)]
+	}
+	return rendererNames[min(i+1, len(rendererNames)-1)]
+}
+
+// saves the maze as an image in the current directory,
+// returns the message for the status line
+func (m maze) exportPNG() string {
+	name := fmt.Sprintf("maze-%s-%d.png", m.opts.algo, m.opts.seed)
+	if m.source != "" {
+		name = strings.TrimSuffix(m.source, filepath.Ext(m.source)) + "-export.png"
+	}
+	if err := m.exportFile(name, "png", defaultScale); err != nil {
+		return fmt.Sprintf("Whops, there's been an error: %v", err)
+	}
+	return "saved " + name
+}
@@ -1,9 +1,15 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/help"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -11,9 +17,172 @@ import (
 // to have double the vertical resolution
 
 func main() {
-	p := tea.NewProgram(
-		NewMaze(20, 20), tea.WithAltScreen(),
-	)
+	algo := flag.String("algo", "binarytree", "generation algorithm, one of: "+strings.Join(generatorNames(), ", "))
+	seed := flag.Int64("seed", 0, "random seed, 0 picks a new one")
+	braidFraction := flag.Float64("braid", 0.2, "fraction of the dead ends removed to make loops, from 0 for a perfect maze to 1 for none;"+
+		" mazes exported bigger than 4096x4096 pixels are perfect unless given")
+	output := flag.String("o", "", "write the maze to this file (- for stdout) and exit, without the interactive view")
+	format := flag.String("format", "", "export format, one of: "+strings.Join(exportFormats, ", ")+" (default: guessed from the file name)")
+	width := flag.Int("width", 81, "maze width in pixels, when not given the interactive view fills the terminal; bigger mazes can be panned")
+	height := flag.Int("height", 41, "maze height in pixels, when not given the interactive view fills the terminal; bigger mazes can be panned")
+	scale := flag.Int("scale", defaultScale, "size in image pixels of each maze pixel for svg and png")
+	input := flag.String("i", "", "load the maze from this file (- for stdin) instead of generating it")
+	inFormat := flag.String("informat", "", "import format, one of: "+strings.Join(importFormats, ", ")+" (default: guessed from the file name)")
+	animate := flag.Bool("animate", false, "show the generation step by step")
+	solve := flag.String("solve", "", "draw the path found by this solver, one of: "+strings.Join(solverNames(), ", ")+
+		"; all compares them and exits")
+	stats := flag.Int("stats", 0, "generate this many mazes with -width and -height, print their statistics and exit;"+
+		" -format json for JSON instead of a table")
+	maskFile := flag.String("mask", "", "carve the maze only inside the shape of this mask, a .png or text file")
+	gridName := flag.String("grid", "rect", "topology of the cells, one of: "+strings.Join(gridNames, ", "))
+	heat := flag.String("heatmap", "", "color the maze by the distance from start, center or x,y")
+	screensaver := flag.Bool("screensaver", false, "show heatmaps of random mazes until a key is pressed")
+	endlessMaze := flag.Bool("endless", false, "scroll a never ending maze up the terminal, carved a row at a time with Eller's algorithm")
+	render := flag.String("render", "auto", "chars used to draw the maze, one of: "+strings.Join(rendererNames, ", ")+
+		"; auto picks the one fitting the terminal")
+	flag.Parse()
+	// the interactive view keeps the size only when explicitly given,
+	// and huge mazes have no loops unless asked
+	fixed, braided := false, false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "width" || f.Name == "height" {
+			fixed = true
+		}
+		braided = braided || f.Name == "braid"
+	})
+	if _, ok := generators[*algo]; !ok {
+		fmt.Printf("Unknown algorithm %q, choose one of: %s\n", *algo, strings.Join(generatorNames(), ", "))
+		os.Exit(1)
+	}
+	if _, ok := solvers[*solve]; !ok && *solve != "" && *solve != "all" {
+		fmt.Printf("Unknown solver %q, choose one of: %s\n", *solve, strings.Join(solverNames(), ", "))
+		os.Exit(1)
+	}
+	if _, ok := renderers[*render]; !ok && *render != "auto" {
+		fmt.Printf("Unknown renderer %q, choose one of: %s\n", *render, strings.Join(rendererNames, ", "))
+		os.Exit(1)
+	}
+	if _, ok := shapes[*gridName]; !ok && *gridName != "rect" && *gridName != "weave" {
+		fmt.Printf("Unknown grid %q, choose one of: %s\n", *gridName, strings.Join(gridNames, ", "))
+		os.Exit(1)
+	}
+	if _, ok := shapes[*gridName]; ok && *maskFile != "" {
+		fmt.Println("Masks only work with the rect and weave grids")
+		os.Exit(1)
+	}
+	if *gridName == "weave" && !slices.Contains(weaveGenerators, *algo) {
+		fmt.Printf("Weave mazes need one of these algorithms: %s\n", strings.Join(weaveGenerators, ", "))
+		os.Exit(1)
+	}
+	if *heat != "" && !validHeatSource(*heat) {
+		fmt.Printf("Unknown heatmap source %q, use start, center or x,y\n", *heat)
+		os.Exit(1)
+	}
+	if *braidFraction < 0 || *braidFraction > 1 {
+		fmt.Println("The braid must be between 0 and 1")
+		os.Exit(1)
+	}
+	if *width < 3 || *height < 3 || *scale < 1 {
+		fmt.Println("The maze must be at least 3x3 and the scale at least 1")
+		os.Exit(1)
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	opts := options{algo: *algo, seed: *seed, braid: *braidFraction, grid: *gridName}
+	if *maskFile != "" {
+		var err error
+		if opts.mask, err = loadMask(*maskFile); err != nil {
+			fmt.Printf("Can't load the mask: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	var loaded *maze
+	if *input != "" {
+		if *inFormat == "" {
+			*inFormat = formatFromName(*input)
+		}
+		m, err := importFile(*input, *inFormat)
+		if err != nil {
+			fmt.Printf("Can't load the maze: %v\n", err)
+			os.Exit(1)
+		}
+		m.source = filepath.Base(*input)
+		loaded = &m
+	}
+	if *stats > 0 {
+		r := collectStats(*width, *height, opts, *stats)
+		if err := r.write(os.Stdout, *format); err != nil {
+			fmt.Printf("Whops, there's been an error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if *solve == "all" {
+		m := NewMaze(*width, *height, opts)
+		if loaded != nil {
+			m = *loaded
+		}
+		fmt.Println(m.status())
+		compareSolvers(os.Stdout, &m)
+		return
+	}
+	if *output != "" {
+		if *format == "" {
+			*format = formatFromName(*output)
+		}
+		if *format == "" {
+			fmt.Println("Can't guess the export format from the file name, please use -format")
+			os.Exit(1)
+		}
+		if loaded == nil && *width**height > hugeMaze {
+			if !braided {
+				opts.braid = 0
+			}
+			if err := exportHuge(*output, *format, *width, *height, opts); err != nil {
+				fmt.Printf("Whops, there's been an error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		var m maze
+		if loaded != nil {
+			m = *loaded
+		} else {
+			m = NewMaze(*width, *height, opts)
+		}
+		if err := m.exportFile(*output, *format, *scale); err != nil {
+			fmt.Printf("Whops, there's been an error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	start := NewMaze(20, 20, opts)
+	if fixed {
+		start = NewMaze(*width, *height, opts)
+	}
+	if loaded != nil {
+		start = *loaded
+	}
+	start.renderer, start.fixed = *render, fixed
+	start.help = help.New()
+	start.setSolver(*solve)
+	start.setHeatmap(*heat)
+	var m tea.Model = start
+	if *animate {
+		if loaded != nil {
+			fmt.Println("Only generated mazes can be animated")
+			os.Exit(1)
+		}
+		m = newAnimation(20, 20, opts)
+	}
+	if *screensaver {
+		m = newScreensaver(opts)
+	}
+	if *endlessMaze {
+		m = newEndless(opts)
+	}
+	p := tea.NewProgram(m, tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("Whops, there's been an error: %v", err)
 		os.Exit(1)
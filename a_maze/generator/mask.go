@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// a mask gives the shape of the maze, like in "Mazes for Programmers":
+// in a text mask 'X' and '#' are off and every other char is on,
+// in an image the dark and transparent pixels are off
+type mask struct {
+	name   string
+	width  int
+	height int
+	on     []bool
+}
+
+func loadMask(name string) (*mask, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var k *mask
+	if strings.ToLower(filepath.Ext(name)) == ".png" {
+		k, err = readImageMask(f)
+	} else {
+		k, err = readTextMask(f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	k.name = filepath.Base(name)
+	return k, nil
+}
+
+func readTextMask(f *os.File) (*mask, error) {
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimRight(scanner.Text(), "\r"))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	k := &mask{height: len(lines)}
+	for _, line := range lines {
+		k.width = max(k.width, len(line))
+	}
+	if k.width == 0 {
+		return nil, fmt.Errorf("empty mask")
+	}
+	// short lines are off at the end
+	k.on = make([]bool, k.width*k.height)
+	for y, line := range lines {
+		for x, ch := range []byte(line) {
+			k.on[y*k.width+x] = ch != 'X' && ch != '#'
+		}
+	}
+	return k, nil
+}
+
+func readImageMask(f *os.File) (*mask, error) {
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, err
+	}
+	b := img.Bounds()
+	k := &mask{width: b.Dx(), height: b.Dy(), on: make([]bool, b.Dx()*b.Dy())}
+	for y := 0; y < k.height; y++ {
+		for x := 0; x < k.width; x++ {
+			_, _, _, a := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+			k.on[y*k.width+x] = a >= 0x8000 && !isDark(img, b.Min.X+x, b.Min.Y+y)
+		}
+	}
+	return k, nil
+}
+
+// scales the mask to a grid of cells keeping its proportions,
+// centered with the unused border off. Only the biggest connected
+// part is kept, because a maze can't join separate islands
+func (k *mask) fit(cols, rows int) []bool {
+	active := make([]bool, cols*rows)
+	if cols == 0 || rows == 0 {
+		return active
+	}
+	scale := min(float64(cols)/float64(k.width), float64(rows)/float64(k.height))
+	w, h := int(float64(k.width)*scale), int(float64(k.height)*scale)
+	left, top := (cols-w)/2, (rows-h)/2
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			mx := min(int(float64(x)/scale), k.width-1)
+			my := min(int(float64(y)/scale), k.height-1)
+			active[(top+y)*cols+left+x] = k.on[my*k.width+mx]
+		}
+	}
+	return largestRegion(active, cols, rows)
+}
+
+// turns off every cell not in the biggest group of adjacent active cells
+func largestRegion(active []bool, cols, rows int) []bool {
+	region := make([]int, len(active))
+	var sizes []int
+	for start, on := range active {
+		if !on || region[start] != 0 {
+			continue
+		}
+		sizes = append(sizes, 0)
+		id := len(sizes)
+		region[start] = id
+		queue := []int{start}
+		for len(queue) > 0 {
+			c := queue[0]
+			queue = queue[1:]
+			sizes[id-1]++
+			x, y := c%cols, c/cols
+			for _, d := range directions {
+				nx, ny := x+d.x, y+d.y
+				n := ny*cols + nx
+				if nx >= 0 && ny >= 0 && nx < cols && ny < rows && active[n] && region[n] == 0 {
+					region[n] = id
+					queue = append(queue, n)
+				}
+			}
+		}
+	}
+	biggest := 0
+	for i, size := range sizes {
+		if size > sizes[biggest] {
+			biggest = i
+		}
+	}
+	result := make([]bool, len(active))
+	for c, id := range region {
+		result[c] = id == biggest+1
+	}
+	return result
+}
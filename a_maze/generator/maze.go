@@ -2,10 +2,11 @@ package main
 
 import (
 	"fmt"
-	"strings"
 
 	"math/rand"
 
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -14,58 +15,76 @@ type maze struct {
 	cells  []byte
 	width  int
 	height int
+	opts   options
+	mask   []bool       // active cells, nil when they all are
+	shape  shape        // the cells when the grid is not rectangular
+	under  map[int]bool // crossings of a weave maze, see weave.go
+	source string       // file name for loaded mazes, empty when generated
+	steps  []step       // every link, only when recorded for the animation
+	solver string       // name of the solver drawing its path, if any
+	solved solution
+	heat   *heatmap // distances colored from a source, nil for none
+	// name of the renderer, auto picks the one fitting the terminal
+	renderer   string
+	fixed      bool // when true, the size doesn't follow the terminal
+	termWidth  int
+	termHeight int
+	left, top  int        // upper left pixel on screen, see viewport.go
+	help       help.Model // the key bindings under the maze
+	message    string     // the outcome of the last command, like an export
+}
+
+// everything needed to generate the same maze again
+type options struct {
+	algo  string  // name of the generation algorithm
+	seed  int64   // same seed, size and options always give the same maze
+	braid float64 // fraction of the dead ends removed, 0 for a perfect maze
+	mask  *mask   // shape of the maze, nil for a plain rectangle
+	grid  string  // topology of the cells, empty for the rectangle
+}
+
+// a single carving step: a passage opened from cell a to cell b
+type step struct {
+	a, b int
 }
 
 // use official SUSE colors for default background and foreground
 // Midnight background, Waterhole foreground
 // https://brand.suse.com/design-language#color
-var baseStyle = lipgloss.NewStyle().Background(lipgloss.Color("#192072")).Foreground(lipgloss.Color("#2453ff"))
+var bgColor = lipgloss.Color("#192072")
+var wallColor = lipgloss.Color("#2453ff")
+var baseStyle = lipgloss.NewStyle().Background(bgColor).Foreground(wallColor)
 
-// 0 = empty space
-// 1 = only bottom filled
-// 2 = only top filled
-// 3 = both filled
-var valToRune = [4]rune{' ', '\u2584', '\u2580', '\u2588'}
+func NewMaze(w, h int, opts options) maze {
+	return generate(w, h, opts, false)
+}
 
-func NewMaze(w, h int) maze {
+// same as NewMaze, optionally recording every carving step
+func generate(w, h int, opts options, record bool) maze {
 	cells := make([]byte, w*h)
-	m := maze{cells: cells, width: w, height: h}
-	// draw borders
-	for x := 0; x < w; x++ {
-		m.set(x, 0, 1)
-		m.set(x, h-1, 1)
-	}
-	for y := 0; y < h; y++ {
-		m.set(0, y, 1)
-		m.set(w-1, y, 1)
-	}
-	// draw grid on alternate cells
-	for x := 2; x < w-2; x += 2 {
-		for y := 1; y < h-1; y++ {
-			m.set(x, y, 1)
-		}
+	m := maze{cells: cells, width: w, height: h, opts: opts}
+	// never use the global source, or the maze can't be reproduced
+	rng := rand.New(rand.NewSource(opts.seed))
+	// start completely filled, the generator carves the passages
+	for i := range m.cells {
+		m.cells[i] = 1
 	}
-	for y := 2; y < h-2; y += 2 {
-		for x := 1; x < w-1; x++ {
-			m.set(x, y, 1)
-		}
+	if opts.mask != nil {
+		m.mask = opts.mask.fit(m.cols(), m.rows())
 	}
-	// starting on the upper row, for each cell
-	// flip a coin in order to decide which direction to carve
-	for y := 1; y < h-1; y += 2 {
-		for x := 1; x < w-1; x += 2 {
-			if rand.Intn(2) == 1 {
-				m.set(x+1, y, 0)
-			} else {
-				m.set(x, y+1, 0)
-			}
-		}
+	m.shape = newShape(opts.grid, w, h)
+	if opts.grid == "weave" {
+		m.under = map[int]bool{}
 	}
-	// carve some extra random spots (20%)
-	for i := 0; i < (w*h)/5; i++ {
-		m.set(2+rand.Intn(w-3), 2+rand.Intn(h-3), 0)
+	g := m.grid()
+	if record {
+		g = recorder{g, &m.steps}
+	}
+	generators[opts.algo].Generate(g, rng)
+	braid(g, opts.braid, rng)
+	if m.shape != nil {
+		m.draw()
 	}
-
 	return m
 }
 
@@ -79,22 +98,13 @@ func (m *maze) set(x, y int, value byte) {
 	m.cells[i] = value
 }
 
-// returns a string representing our model
-func (m maze) toString() string {
-	var sb strings.Builder
-	y := 0
-	for y < m.height {
-		for x := 0; x < m.width; x++ {
-			up := x + y*m.width
-			down := up + m.width
-			sb.WriteRune(valToRune[2*m.cells[up]+m.cells[down]])
-		}
-		y += 2
-		if y < m.height-1 {
-			sb.WriteRune('\n')
-		}
+// outside of the maze everything is filled
+func (m *maze) get(x, y int) byte {
+	i := y*m.width + x
+	if i > len(m.cells)-1 || x < 0 || y < 0 || x >= m.width || y >= m.height {
+		return 1
 	}
-	return sb.String()
+	return m.cells[i]
 }
 
 // nothing to do on startup
@@ -107,17 +117,182 @@ func (m maze) Init() tea.Cmd {
 func (m maze) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		return m, tea.Quit
+		m.message = ""
+		opts := m.opts
+		switch {
+		case key.Matches(msg, keys.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, keys.Help):
+			m.help.ShowAll = !m.help.ShowAll
+			return m.fitted(), nil
+		case key.Matches(msg, keys.Solver):
+			m.setSolver(nextSolver(m.solver))
+			return m, nil
+		case key.Matches(msg, keys.ZoomIn):
+			m.renderer = m.zoomed(true)
+			return m.fitted(), nil
+		case key.Matches(msg, keys.ZoomOut):
+			m.renderer = m.zoomed(false)
+			return m.fitted(), nil
+		case key.Matches(msg, keys.Export):
+			m.message = m.exportPNG()
+			return m, nil
+		case key.Matches(msg, keys.Up):
+			m.pan(0, -1)
+			return m, nil
+		case key.Matches(msg, keys.Down):
+			m.pan(0, 1)
+			return m, nil
+		case key.Matches(msg, keys.Left):
+			m.pan(-1, 0)
+			return m, nil
+		case key.Matches(msg, keys.Right):
+			m.pan(1, 0)
+			return m, nil
+		case key.Matches(msg, keys.PageUp):
+			m.pan(0, -(m.termHeight - m.footerHeight()))
+			return m, nil
+		case key.Matches(msg, keys.PageDown):
+			m.pan(0, m.termHeight-m.footerHeight())
+			return m, nil
+		// the rest only makes sense for generated mazes
+		case m.source != "":
+			return m, nil
+		case key.Matches(msg, keys.Regenerate):
+			opts.seed = rand.Int63()
+		case key.Matches(msg, keys.NextSeed):
+			opts.seed++
+		case key.Matches(msg, keys.PrevSeed):
+			opts.seed--
+		case key.Matches(msg, keys.Algorithm):
+			opts.algo = nextAlgorithm(opts)
+		default:
+			return m, nil
+		}
+		m.opts = opts
+		return m.resized(m.width, m.height), nil
 	case tea.WindowSizeMsg:
-		return NewMaze(msg.Width, msg.Height*2), nil
+		m.termWidth, m.termHeight = msg.Width, msg.Height
+		m.help.Width = msg.Width
+		return m.fitted(), nil
 	default:
 		return m, nil
 	}
 }
 
-// this must return a string rapresentation of our model
+// the maze filling the terminal, above the footer.
+// Loaded mazes and the ones with a given size never change
+func (m maze) fitted() maze {
+	if m.source != "" || m.fixed || m.termWidth == 0 {
+		return m
+	}
+	bw, bh := m.chars().Size()
+	return m.resized(m.termWidth*bw, (m.termHeight-m.footerHeight())*bh)
+}
+
+// this must return a string rapresentation of our model,
+// only the part in the viewport when it doesn't fit.
+// Weave mazes are always drawn with their passages
 func (m maze) View() string {
-	return baseStyle.Render(m.toString())
+	v := m.viewport()
+	if m.solver == "" && m.heat == nil && m.under != nil {
+		return baseStyle.Render(m.passages(v, nil)) + "\n" + m.footer()
+	}
+	if m.solver == "" && m.heat == nil {
+		return baseStyle.Render(m.toString(m.chars(), v)) + "\n" + m.footer()
+	}
+	path := m.solved.pixels()
+	paint := func(x, y int) lipgloss.TerminalColor {
+		if path[y*m.width+x] {
+			return pathColor
+		}
+		if m.heat != nil {
+			return m.heat.color(y*m.width+x, m.heat.max)
+		}
+		return nil
+	}
+	if m.under != nil {
+		return m.passages(v, paint) + "\n" + m.footer()
+	}
+	return m.render(m.chars(), v, paint) + "\n" + m.footer()
+}
+
+// generates a new maze with the same settings
+func (m maze) resized(w, h int) maze {
+	n := NewMaze(w, h, m.opts)
+	n.renderer, n.fixed = m.renderer, m.fixed
+	n.termWidth, n.termHeight = m.termWidth, m.termHeight
+	n.help, n.message = m.help, m.message
+	n.left, n.top = m.left, m.top
+	n.setSolver(m.solver)
+	if m.heat != nil {
+		n.setHeatmap(m.heat.spec)
+	}
+	return n
+}
+
+// the renderer in use: when the maze has its own size, auto picks
+// the coarsest one that fits, otherwise the maze fills the terminal
+// with half blocks
+func (m maze) chars() Renderer {
+	if r, ok := renderers[m.renderer]; ok {
+		return r
+	}
+	if m.source != "" || m.fixed {
+		return fittingRenderer(m.width, m.height, m.termWidth, m.termHeight-m.footerHeight())
+	}
+	return halfBlocks{}
+}
+
+// solves the maze again with the named solver, empty for none
+func (m *maze) setSolver(name string) {
+	m.solver = name
+	if name != "" {
+		m.solved = m.solve(name)
+	}
+}
+
+// colors the maze by the distance from the source in spec, empty for none
+func (m *maze) setHeatmap(spec string) {
+	m.heat = nil
+	if spec != "" {
+		h := m.heatmap(spec)
+		m.heat = &h
+	}
+}
+
+// status line and key bindings
+func (m maze) footer() string {
+	s := m.status()
+	if m.message != "" {
+		s += " - " + m.message
+	}
+	return s + "\n" + m.help.View(keys)
+}
+
+// a line with the information needed to reproduce the maze
+func (m maze) status() string {
+	s := fmt.Sprintf("%s %dx%d seed %d braid %g", m.opts.algo, m.width, m.height, m.opts.seed, m.opts.braid)
+	if m.source != "" {
+		s = fmt.Sprintf("%s %dx%d", m.source, m.width, m.height)
+	}
+	if m.opts.mask != nil {
+		s += " mask " + m.opts.mask.name
+	}
+	if m.shape != nil || m.under != nil {
+		s += " grid " + m.opts.grid
+	}
+	if m.solver != "" {
+		s += fmt.Sprintf(" - %s: %v", m.solver, m.solved)
+	}
+	if m.heat != nil {
+		s += fmt.Sprintf(" - %v", m.heat)
+	}
+	if m.termWidth > 0 && m.scrolls() {
+		v := m.viewport()
+		s += fmt.Sprintf(" - view %d,%d", v.x0, v.y0)
+	}
+	return s
 }
 
 // utility func for debugging
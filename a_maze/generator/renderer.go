@@ -0,0 +1,207 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// a Renderer packs a block of pixels into a single char.
+// The more pixels in a char, the bigger the maze fitting in the terminal
+type Renderer interface {
+	// pixels per char, horizontally and vertically
+	Size() (int, int)
+	// the char for a block, bit row*width+col is set for each filled pixel
+	Glyph(bits uint) rune
+}
+
+// all the available renderers, from the lowest to the highest resolution
+var rendererNames = []string{"halfblock", "sextant", "braille"}
+
+var renderers = map[string]Renderer{
+	"halfblock": halfBlocks{},
+	"sextant":   sextants{},
+	"braille":   braille{},
+}
+
+// the coarsest renderer showing the whole maze in a w x h chars area
+func fittingRenderer(mazeWidth, mazeHeight, w, h int) Renderer {
+	for _, name := range rendererNames {
+		r := renderers[name]
+		bw, bh := r.Size()
+		if (mazeWidth+bw-1)/bw <= w && (mazeHeight+bh-1)/bh <= h {
+			return r
+		}
+	}
+	return renderers[rendererNames[len(rendererNames)-1]]
+}
+
+// the original one: 1x2 pixels using "half block" unicode chars
+// to have double the vertical resolution
+type halfBlocks struct{}
+
+// 0 = empty space
+// 1 = only bottom filled
+// 2 = only top filled
+// 3 = both filled
+var valToRune = [4]rune{' ', '▄', '▀', '█'}
+
+func (halfBlocks) Size() (int, int) { return 1, 2 }
+
+func (halfBlocks) Glyph(bits uint) rune {
+	return valToRune[2*(bits&1)+bits>>1]
+}
+
+// 2x3 pixels, from the "Symbols for Legacy Computing" unicode block.
+// Not every font has them
+type sextants struct{}
+
+func (sextants) Size() (int, int) { return 2, 3 }
+
+func (sextants) Glyph(bits uint) rune {
+	// the four combinations already existing as block elements
+	// are left out of the sequence
+	switch bits {
+	case 0:
+		return ' '
+	case 0b010101:
+		return '▌'
+	case 0b101010:
+		return '▐'
+	case 0b111111:
+		return '█'
+	}
+	r := rune(0x1fb00 + bits - 1)
+	if bits > 0b010101 {
+		r--
+	}
+	if bits > 0b101010 {
+		r--
+	}
+	return r
+}
+
+// 2x4 dots using braille patterns, the highest resolution
+// but walls are made of dots instead of solid blocks
+type braille struct{}
+
+// the braille bit of each pixel, dots are numbered by column
+var brailleDots = [8]uint{0x01, 0x08, 0x02, 0x10, 0x04, 0x20, 0x40, 0x80}
+
+func (braille) Size() (int, int) { return 2, 4 }
+
+func (braille) Glyph(bits uint) rune {
+	r := rune(0x2800)
+	for i, dot := range brailleDots {
+		if bits&(1<<i) != 0 {
+			r += rune(dot)
+		}
+	}
+	return r
+}
+
+// the walls in the block with upper left corner (x,y),
+// pixels outside of the maze or of its mask are empty
+func (m maze) blockBits(x, y, bw, bh int) uint {
+	var bits uint
+	for i := 0; i < bw*bh; i++ {
+		px, py := x+i%bw, y+i/bw
+		if px < m.width && py < m.height && m.get(px, py) == 1 && m.visible(px, py) {
+			bits |= 1 << i
+		}
+	}
+	return bits
+}
+
+// a rectangle of pixels: x0,y0 included, x1,y1 excluded
+type area struct {
+	x0, y0, x1, y1 int
+}
+
+// all the pixels of the maze
+func (m maze) whole() area {
+	return area{0, 0, m.width, m.height}
+}
+
+// returns a string representing the pixels of our model in a
+func (m maze) toString(r Renderer, a area) string {
+	var sb strings.Builder
+	bw, bh := r.Size()
+	for y := a.y0; y < a.y1; y += bh {
+		for x := a.x0; x < a.x1; x += bw {
+			sb.WriteRune(r.Glyph(m.blockBits(x, y, bw, bh)))
+		}
+		if y+bh < a.y1 {
+			sb.WriteRune('\n')
+		}
+	}
+	return sb.String()
+}
+
+// like toString, but single pixels can be painted with their own color.
+// paint returns nil for the pixels that keep the default colors.
+// A char has only two colors: the painted pixels become the foreground,
+// and if there's no room for the walls they're drawn with the painted color
+func (m maze) render(r Renderer, a area, paint func(x, y int) lipgloss.TerminalColor) string {
+	var sb, plain strings.Builder
+	flush := func() {
+		if plain.Len() > 0 {
+			sb.WriteString(baseStyle.Render(plain.String()))
+			plain.Reset()
+		}
+	}
+	bw, bh := r.Size()
+	colors := make([]lipgloss.TerminalColor, bw*bh)
+	for y := a.y0; y < a.y1; y += bh {
+		for x := a.x0; x < a.x1; x += bw {
+			var fg lipgloss.TerminalColor
+			for i := range colors {
+				px, py := x+i%bw, y+i/bw
+				colors[i] = nil
+				if px < m.width && py < m.height {
+					colors[i] = paint(px, py)
+				}
+				if fg == nil {
+					fg = colors[i]
+				}
+			}
+			if fg == nil {
+				plain.WriteRune(r.Glyph(m.blockBits(x, y, bw, bh)))
+				continue
+			}
+			flush()
+			var bg lipgloss.TerminalColor
+			for i, c := range colors {
+				if c == nil {
+					colors[i] = m.pixelColor(x+i%bw, y+i/bw)
+				}
+				if colors[i] == bgColor || (bg == nil && colors[i] != fg) {
+					bg = colors[i]
+				}
+			}
+			if bg == nil {
+				bg = bgColor
+			}
+			var bits uint
+			for i, c := range colors {
+				if c != bg {
+					bits |= 1 << i
+				}
+			}
+			sb.WriteString(lipgloss.NewStyle().Foreground(fg).Background(bg).Render(string(r.Glyph(bits))))
+		}
+		flush()
+		if y+bh < a.y1 {
+			sb.WriteRune('\n')
+		}
+	}
+	return sb.String()
+}
+
+// the default color of a pixel
+func (m maze) pixelColor(x, y int) lipgloss.TerminalColor {
+	if x < m.width && y < m.height && m.get(x, y) == 1 && m.visible(x, y) {
+		return wallColor
+	}
+	return bgColor
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// an endless show of heatmaps: the distances spread from a random pixel
+// like a wave, then a new maze with a random algorithm takes its place.
+// Any key quits, like every screensaver
+
+const (
+	waveFrames = 120 // frames for the wave to reach the farthest pixel
+	holdFrames = 60  // frames the finished heatmap stays on screen
+)
+
+type screensaver struct {
+	maze  maze
+	heat  heatmap
+	frame int // frames since the maze was generated
+	rng   *rand.Rand
+	opts  options // the settings of every maze, but algorithm and seed
+	// the size of the mazes, zero until the terminal size is known
+	width, height int
+}
+
+func newScreensaver(opts options) screensaver {
+	s := screensaver{rng: rand.New(rand.NewSource(opts.seed)), opts: opts}
+	s.next()
+	return s
+}
+
+// generates the next maze and picks the source of its heatmap
+func (s *screensaver) next() {
+	if s.width == 0 {
+		s.width, s.height = 20, 20
+	}
+	names := generatorNames()
+	if s.opts.grid == "weave" {
+		names = weaveGenerators
+	}
+	s.opts.algo = names[s.rng.Intn(len(names))]
+	s.opts.seed = s.rng.Int63()
+	s.maze = NewMaze(s.width, s.height, s.opts)
+	s.maze.setHeatmap(randomOpenPixel(&s.maze, s.rng))
+	s.heat = *s.maze.heat
+	s.frame = 0
+}
+
+// the coordinates of an open pixel, center when there are none
+func randomOpenPixel(m *maze, rng *rand.Rand) string {
+	var open []int
+	for p, c := range m.cells {
+		if c == 0 {
+			open = append(open, p)
+		}
+	}
+	if len(open) == 0 {
+		return "center"
+	}
+	p := open[rng.Intn(len(open))]
+	return fmt.Sprintf("%d,%d", p%m.width, p/m.width)
+}
+
+func (s screensaver) tick() tea.Cmd {
+	return tea.Tick(defaultDelay, func(_ time.Time) tea.Msg {
+		return frameMsg{}
+	})
+}
+
+func (s screensaver) Init() tea.Cmd {
+	return s.tick()
+}
+
+func (s screensaver) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		return s, tea.Quit
+	case tea.WindowSizeMsg:
+		s.width, s.height = msg.Width, (msg.Height-1)*2
+		s.next()
+	case frameMsg:
+		s.frame++
+		if s.frame > waveFrames+holdFrames {
+			s.next()
+		}
+		return s, s.tick()
+	}
+	return s, nil
+}
+
+func (s screensaver) View() string {
+	limit := s.heat.max * min(s.frame, waveFrames) / waveFrames
+	paint := func(x, y int) lipgloss.TerminalColor {
+		if s.heat.from < 0 {
+			return nil
+		}
+		return s.heat.color(y*s.maze.width+x, limit)
+	}
+	if s.maze.under != nil {
+		return s.maze.passages(s.maze.whole(), paint) + "\n" + s.maze.status()
+	}
+	return s.maze.render(halfBlocks{}, s.maze.whole(), paint) + "\n" + s.maze.status()
+}
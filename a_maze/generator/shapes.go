@@ -0,0 +1,369 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+// the topologies other than the rectangle, like in "Mazes for Programmers".
+// Sizes are in pixels: shapes need bigger cells than the rectangle to be
+// recognizable, so they look best with the sextant and braille renderers
+
+// hexagons with a pointy top, odd rows are shifted half a cell right
+type hexGrid struct {
+	shapeCells
+	cols int
+	rows int
+	// center of the first cell
+	left float64
+	top  float64
+}
+
+// distance from the center of a hexagon to its corners
+const hexSize = 4.0
+
+func newHexGrid(w, h int) shape {
+	g := &hexGrid{}
+	cw := sqrt3 * hexSize
+	g.cols = int(math.Floor(float64(w)/cw - 0.5))
+	g.rows = int(math.Floor((float64(h)-2*hexSize)/(1.5*hexSize))) + 1
+	if g.cols < 1 || g.rows < 1 {
+		g.cols, g.rows = 0, 0
+	}
+	// centered in the area
+	g.left = (float64(w)-cw*(float64(g.cols)+0.5))/2 + cw/2
+	g.top = (float64(h)-1.5*hexSize*float64(g.rows-1)-2*hexSize)/2 + hexSize
+	g.shapeCells = newShapeCells(w, h, g.cols*g.rows, g.locate)
+	return g
+}
+
+// converts to axial coordinates and rounds to the nearest hexagon
+func (g *hexGrid) locate(x, y float64) int {
+	px, py := x-g.left, y-g.top
+	q := (sqrt3/3*px - py/3) / hexSize
+	r := 2.0 / 3 * py / hexSize
+	// the third cube coordinate, the one with the biggest error is fixed
+	s := -q - r
+	rq, rr, rs := math.Round(q), math.Round(r), math.Round(s)
+	dq, dr, ds := math.Abs(rq-q), math.Abs(rr-r), math.Abs(rs-s)
+	if dq > dr && dq > ds {
+		rq = -rr - rs
+	} else if dr > ds {
+		rr = -rq - rs
+	}
+	row := int(rr)
+	return g.cellAt(int(rq)+(row-(row&1))/2, row)
+}
+
+// -1 outside of the grid
+func (g *hexGrid) cellAt(col, row int) int {
+	if col < 0 || row < 0 || col >= g.cols || row >= g.rows {
+		return -1
+	}
+	return row*g.cols + col
+}
+
+// the six neighbours clockwise from east, -1 where there's none.
+// Side k of a hexagon faces the direction 60*k degrees
+func (g *hexGrid) sides(c int) [6]int {
+	col, row := c%g.cols, c/g.cols
+	// odd rows are shifted right
+	shift := row & 1
+	return [6]int{
+		g.cellAt(col+1, row),
+		g.cellAt(col+shift, row+1),
+		g.cellAt(col+shift-1, row+1),
+		g.cellAt(col-1, row),
+		g.cellAt(col+shift-1, row-1),
+		g.cellAt(col+shift, row-1),
+	}
+}
+
+func (g *hexGrid) neighbours(c int) []int {
+	s := g.sides(c)
+	return existing(s[:]...)
+}
+
+func (g *hexGrid) cellRows() [][]int {
+	counts := make([]int, g.rows)
+	for r := range counts {
+		counts[r] = g.cols
+	}
+	return rowsOf(counts)
+}
+
+func (g *hexGrid) centerXY(c int) (float64, float64) {
+	col, row := c%g.cols, c/g.cols
+	return g.left + sqrt3*hexSize*(float64(col)+0.5*float64(row&1)), g.top + 1.5*hexSize*float64(row)
+}
+
+func (g *hexGrid) center(c int) (int, int) {
+	x, y := g.centerXY(c)
+	return int(x), int(y)
+}
+
+func (g *hexGrid) svgWalls(w io.Writer, scale int) {
+	for c := 0; c < g.cellCount(); c++ {
+		x, y := g.centerXY(c)
+		for k, n := range g.sides(c) {
+			if n >= 0 && (n < c || g.linked(c, n)) {
+				continue
+			}
+			// the corners of side k are 30 degrees before and after it
+			a1, a2 := float64(2*k-1)*math.Pi/6, float64(2*k+1)*math.Pi/6
+			svgLine(w, scale, x+hexSize*math.Cos(a1), y+hexSize*math.Sin(a1), x+hexSize*math.Cos(a2), y+hexSize*math.Sin(a2))
+		}
+	}
+}
+
+// rows of triangles pointing alternately up and down
+type triangleGrid struct {
+	shapeCells
+	cols int
+	rows int
+	// upper left corner of the grid
+	left float64
+	top  float64
+}
+
+const triangleSide = 8.0
+
+var triangleHeight = triangleSide * sqrt3 / 2
+
+func newTriangleGrid(w, h int) shape {
+	g := &triangleGrid{}
+	// every triangle overlaps half of the next one
+	g.cols = int(math.Floor(2*float64(w)/triangleSide)) - 1
+	g.rows = int(math.Floor(float64(h) / triangleHeight))
+	if g.cols < 1 || g.rows < 1 {
+		g.cols, g.rows = 0, 0
+	}
+	g.left = (float64(w) - float64(g.cols+1)*triangleSide/2) / 2
+	g.top = (float64(h) - float64(g.rows)*triangleHeight) / 2
+	g.shapeCells = newShapeCells(w, h, g.cols*g.rows, g.locate)
+	return g
+}
+
+func upward(col, row int) bool {
+	return (col+row)&1 == 0
+}
+
+// every strip half a side wide is split by a diagonal between two triangles
+func (g *triangleGrid) locate(x, y float64) int {
+	fy := (y - g.top) / triangleHeight
+	row := math.Floor(fy)
+	fy -= row
+	u := (x - g.left) / (triangleSide / 2)
+	k := math.Floor(u)
+	t := u - k
+	col := int(k)
+	if upward(col, int(row)) && t+fy < 1 || !upward(col, int(row)) && t < fy {
+		col--
+	}
+	return g.cellAt(col, int(row))
+}
+
+// -1 outside of the grid
+func (g *triangleGrid) cellAt(col, row int) int {
+	if col < 0 || row < 0 || col >= g.cols || row >= g.rows {
+		return -1
+	}
+	return row*g.cols + col
+}
+
+// the neighbours on the left, on the right and across the horizontal side,
+// -1 where there's none
+func (g *triangleGrid) sides(c int) [3]int {
+	col, row := c%g.cols, c/g.cols
+	across := g.cellAt(col, row-1)
+	if upward(col, row) {
+		across = g.cellAt(col, row+1)
+	}
+	return [3]int{g.cellAt(col-1, row), g.cellAt(col+1, row), across}
+}
+
+func (g *triangleGrid) neighbours(c int) []int {
+	s := g.sides(c)
+	return existing(s[:]...)
+}
+
+func (g *triangleGrid) cellRows() [][]int {
+	counts := make([]int, g.rows)
+	for r := range counts {
+		counts[r] = g.cols
+	}
+	return rowsOf(counts)
+}
+
+// the corners on the left, at the tip and on the right
+func (g *triangleGrid) corners(c int) [3][2]float64 {
+	col, row := c%g.cols, c/g.cols
+	x := g.left + float64(col)*triangleSide/2
+	top, bottom := g.top+float64(row)*triangleHeight, g.top+float64(row+1)*triangleHeight
+	if upward(col, row) {
+		return [3][2]float64{{x, bottom}, {x + triangleSide/2, top}, {x + triangleSide, bottom}}
+	}
+	return [3][2]float64{{x, top}, {x + triangleSide/2, bottom}, {x + triangleSide, top}}
+}
+
+func (g *triangleGrid) center(c int) (int, int) {
+	p := g.corners(c)
+	return int((p[0][0] + p[1][0] + p[2][0]) / 3), int((p[0][1] + p[1][1] + p[2][1]) / 3)
+}
+
+func (g *triangleGrid) svgWalls(w io.Writer, scale int) {
+	for c := 0; c < g.cellCount(); c++ {
+		p := g.corners(c)
+		// left side, right side and horizontal side
+		edges := [3][2][2]float64{{p[0], p[1]}, {p[1], p[2]}, {p[0], p[2]}}
+		for k, n := range g.sides(c) {
+			if n >= 0 && (n < c || g.linked(c, n)) {
+				continue
+			}
+			svgLine(w, scale, edges[k][0][0], edges[k][0][1], edges[k][1][0], edges[k][1][1])
+		}
+	}
+}
+
+// concentric rings around a single cell in the middle,
+// a ring has twice the cells of the inner one when they get too wide
+type polarGrid struct {
+	shapeCells
+	counts []int // cells in each ring
+	first  []int // the first cell of each ring
+	ring   []int // the ring of each cell
+	// center of the circle
+	x float64
+	y float64
+}
+
+// thickness of the rings
+const ringSize = 4.0
+
+func newPolarGrid(w, h int) shape {
+	g := &polarGrid{x: float64(w) / 2, y: float64(h) / 2}
+	rings := int(float64(min(w, h)) / 2 / ringSize)
+	cells := 0
+	for i := 0; i < rings; i++ {
+		n := 1
+		if i > 0 {
+			// cells as wide as the rings are thick
+			n = g.counts[i-1] * max(1, int(math.Round(2*math.Pi*float64(i)/float64(g.counts[i-1]))))
+		}
+		g.counts = append(g.counts, n)
+		g.first = append(g.first, cells)
+		for j := 0; j < n; j++ {
+			g.ring = append(g.ring, i)
+		}
+		cells += n
+	}
+	g.shapeCells = newShapeCells(w, h, cells, g.locate)
+	return g
+}
+
+// angles grow clockwise, because y grows down
+func (g *polarGrid) locate(x, y float64) int {
+	dx, dy := x-g.x, y-g.y
+	ring := int(math.Hypot(dx, dy) / ringSize)
+	if ring >= len(g.counts) {
+		return -1
+	}
+	a := math.Atan2(dy, dx)
+	if a < 0 {
+		a += 2 * math.Pi
+	}
+	i := min(int(a/(2*math.Pi)*float64(g.counts[ring])), g.counts[ring]-1)
+	return g.first[ring] + i
+}
+
+// the cells of the inner ring, clockwise and counterclockwise,
+// -1 for the cell in the middle
+func (g *polarGrid) around(c int) (in, cw, ccw int) {
+	ring := g.ring[c]
+	if ring == 0 {
+		return -1, -1, -1
+	}
+	n, i := g.counts[ring], c-g.first[ring]
+	in = g.first[ring-1] + i/(n/g.counts[ring-1])
+	return in, g.first[ring] + (i+1)%n, g.first[ring] + (i+n-1)%n
+}
+
+// the cells of the outer ring touching c
+func (g *polarGrid) outward(c int) []int {
+	ring := g.ring[c]
+	if ring == len(g.counts)-1 {
+		return nil
+	}
+	ratio := g.counts[ring+1] / g.counts[ring]
+	first := g.first[ring+1] + (c-g.first[ring])*ratio
+	result := make([]int, ratio)
+	for k := range result {
+		result[k] = first + k
+	}
+	return result
+}
+
+func (g *polarGrid) neighbours(c int) []int {
+	in, cw, ccw := g.around(c)
+	return append(existing(in, ccw, cw), g.outward(c)...)
+}
+
+func (g *polarGrid) cellRows() [][]int {
+	return rowsOf(g.counts)
+}
+
+// the angles where a cell starts and ends
+func (g *polarGrid) angles(c int) (float64, float64) {
+	ring := g.ring[c]
+	step := 2 * math.Pi / float64(g.counts[ring])
+	i := float64(c - g.first[ring])
+	return i * step, (i + 1) * step
+}
+
+func (g *polarGrid) point(a, r float64) (float64, float64) {
+	return g.x + r*math.Cos(a), g.y + r*math.Sin(a)
+}
+
+func (g *polarGrid) center(c int) (int, int) {
+	if g.ring[c] == 0 {
+		return int(g.x), int(g.y)
+	}
+	a1, a2 := g.angles(c)
+	x, y := g.point((a1+a2)/2, (float64(g.ring[c])+0.5)*ringSize)
+	return int(x), int(y)
+}
+
+func (g *polarGrid) svgWalls(w io.Writer, scale int) {
+	s := float64(scale)
+	for c := 0; c < g.cellCount(); c++ {
+		in, cw, _ := g.around(c)
+		if in < 0 {
+			continue
+		}
+		a1, a2 := g.angles(c)
+		inner := float64(g.ring[c]) * ringSize
+		if !g.linked(c, in) {
+			x1, y1 := g.point(a1, inner)
+			x2, y2 := g.point(a2, inner)
+			fmt.Fprintf(w, "<path d=\"M %.2f %.2f A %.2f %.2f 0 0 1 %.2f %.2f\" fill=\"none\" stroke=\"black\" stroke-width=\"%d\"/>\n",
+				x1*s, y1*s, inner*s, inner*s, x2*s, y2*s, scale)
+		}
+		if !g.linked(c, cw) {
+			x1, y1 := g.point(a2, inner)
+			x2, y2 := g.point(a2, inner+ringSize)
+			svgLine(w, scale, x1, y1, x2, y2)
+		}
+	}
+	if len(g.counts) > 0 {
+		fmt.Fprintf(w, "<circle cx=\"%.2f\" cy=\"%.2f\" r=\"%.2f\" fill=\"none\" stroke=\"black\" stroke-width=\"%d\"/>\n",
+			g.x*s, g.y*s, float64(len(g.counts))*ringSize*s, scale)
+	}
+}
+
+// a wall from (x1,y1) to (x2,y2), in maze pixels
+func svgLine(w io.Writer, scale int, x1, y1, x2, y2 float64) {
+	s := float64(scale)
+	fmt.Fprintf(w, "<line x1=\"%.2f\" y1=\"%.2f\" x2=\"%.2f\" y2=\"%.2f\" stroke=\"black\" stroke-width=\"%d\" stroke-linecap=\"round\"/>\n",
+		x1*s, y1*s, x2*s, y2*s, scale)
+}
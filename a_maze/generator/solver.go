@@ -0,0 +1,372 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// solvers work on the pixels, not on the cells, so they also handle
+// the mazes loaded from files.
+// Pixels are identified by their index y*width+x
+
+// the solution path, in SUSE Jungle green to stand out on the blue maze
+var pathColor = lipgloss.Color("#30ba78")
+
+// a Solver looks for a path between two open pixels
+type Solver interface {
+	Solve(m *maze, from, to int) solution
+}
+
+type solution struct {
+	path    []int // from start to goal included, empty if there's no way
+	visited int   // number of pixels the solver had to look at
+}
+
+// all the available solvers, selectable by name from the command line
+var solvers = map[string]Solver{
+	"bfs":        bfs{},
+	"dfs":        dfs{},
+	"astar":      astar{},
+	"deadend":    deadEndFilling{},
+	"wallfollow": wallFollower{},
+}
+
+// returns the sorted list of solver names
+func solverNames() []string {
+	names := make([]string, 0, len(solvers))
+	for name := range solvers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// the first and the last open pixels, usually the upper left
+// and the lower right corners. Returns false if there's no such pixels
+func (m *maze) endpoints() (int, int, bool) {
+	// the corners of a shape may be cut off by its walls: start from the
+	// first pixel along the outer wall, and end in the last cell along
+	// a wall, so the wall follower can find the way between them
+	if m.shape != nil {
+		if m.shape.cellCount() < 2 {
+			return 0, 0, false
+		}
+		return m.firstAlongBorder(), m.lastAlongWall(m.shape.cellCount() - 1), true
+	}
+	from, to := -1, -1
+	for i, c := range m.cells {
+		if c == 0 {
+			if from < 0 {
+				from = i
+			}
+			to = i
+		}
+	}
+	return from, to, from >= 0 && from != to
+}
+
+// the first open pixel next to the outer wall of the shape: the first
+// cell isn't always on the border, the center of a polar grid isn't
+func (m *maze) firstAlongBorder() int {
+	outside := func(x, y int) bool {
+		for _, d := range directions {
+			if m.shape.cellAtPixel(x+d.x, y+d.y) < 0 {
+				return true
+			}
+		}
+		return false
+	}
+	for i, c := range m.cells {
+		x, y := i%m.width, i/m.width
+		if c != 0 {
+			continue
+		}
+		for _, d := range directions {
+			if m.get(x+d.x, y+d.y) == 1 && outside(x+d.x, y+d.y) {
+				return i
+			}
+		}
+	}
+	return 0
+}
+
+// the last pixel of a cell next to a wall, among the ones reachable from its
+// center without leaving it. The last cell is on the border of every shape,
+// so there's always one
+func (m *maze) lastAlongWall(c int) int {
+	x, y := m.shape.center(c)
+	start := y*m.width + x
+	last := start
+	seen := map[int]bool{start: true}
+	queue := []int{start}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		open := m.openAround(p)
+		if len(open) < 4 && p > last {
+			last = p
+		}
+		for _, q := range open {
+			if !seen[q] && m.shape.cellAtPixel(q%m.width, q/m.width) == c {
+				seen[q] = true
+				queue = append(queue, q)
+			}
+		}
+	}
+	return last
+}
+
+// runs a solver between the endpoints
+func (m *maze) solve(name string) solution {
+	from, to, ok := m.endpoints()
+	if !ok {
+		return solution{}
+	}
+	return solvers[name].Solve(m, from, to)
+}
+
+// the open pixels next to p: north, east, south and west,
+// or beyond them for the crossings of a weave maze
+func (m *maze) openAround(p int) []int {
+	result := make([]int, 0, 4)
+	for _, d := range directions {
+		if q, ok := m.next(p, d); ok {
+			result = append(result, q)
+		}
+	}
+	return result
+}
+
+// clockwise, starting from north
+var directions = [4]struct{ x, y int }{{0, -1}, {1, 0}, {0, 1}, {-1, 0}}
+
+// walks back the parents from the goal
+func pathTo(parent map[int]int, from, to int) []int {
+	if _, ok := parent[to]; !ok {
+		return nil
+	}
+	path := []int{to}
+	for p := to; p != from; {
+		p = parent[p]
+		path = append(path, p)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// breadth-first search, always finds the shortest path
+type bfs struct{}
+
+func (bfs) Solve(m *maze, from, to int) solution {
+	parent := map[int]int{from: from}
+	queue := []int{from}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		if p == to {
+			break
+		}
+		for _, n := range m.openAround(p) {
+			if _, seen := parent[n]; !seen {
+				parent[n] = p
+				queue = append(queue, n)
+			}
+		}
+	}
+	return solution{path: pathTo(parent, from, to), visited: len(parent)}
+}
+
+// depth-first search, follows each corridor until the end
+type dfs struct{}
+
+func (dfs) Solve(m *maze, from, to int) solution {
+	parent := map[int]int{from: from}
+	stack := []int{from}
+	for len(stack) > 0 {
+		p := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if p == to {
+			break
+		}
+		for _, n := range m.openAround(p) {
+			if _, seen := parent[n]; !seen {
+				parent[n] = p
+				stack = append(stack, n)
+			}
+		}
+	}
+	return solution{path: pathTo(parent, from, to), visited: len(parent)}
+}
+
+// A* with the manhattan distance, shortest path like BFS
+// but usually looking at fewer pixels
+type astar struct{}
+
+func (astar) Solve(m *maze, from, to int) solution {
+	distance := func(p int) int {
+		return abs(p%m.width-to%m.width) + abs(p/m.width-to/m.width)
+	}
+	parent := map[int]int{from: from}
+	cost := map[int]int{from: 0}
+	open := &priorityQueue{{from, distance(from)}}
+	for open.Len() > 0 {
+		p := heap.Pop(open).(queueItem).pixel
+		if p == to {
+			break
+		}
+		for _, n := range m.openAround(p) {
+			c := cost[p] + 1
+			if old, seen := cost[n]; seen && old <= c {
+				continue
+			}
+			cost[n] = c
+			parent[n] = p
+			heap.Push(open, queueItem{n, c + distance(n)})
+		}
+	}
+	return solution{path: pathTo(parent, from, to), visited: len(parent)}
+}
+
+type queueItem struct {
+	pixel    int
+	priority int
+}
+
+// min-heap of pixels for A*
+type priorityQueue []queueItem
+
+func (q priorityQueue) Len() int           { return len(q) }
+func (q priorityQueue) Less(i, j int) bool { return q[i].priority < q[j].priority }
+func (q priorityQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *priorityQueue) Push(x any)        { *q = append(*q, x.(queueItem)) }
+func (q *priorityQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// fills every dead end until only the corridors leading somewhere remain.
+// On a perfect maze that's exactly the solution, with loops a BFS
+// on what's left picks one of the paths
+type deadEndFilling struct{}
+
+func (deadEndFilling) Solve(m *maze, from, to int) solution {
+	filled := maze{cells: make([]byte, len(m.cells)), width: m.width, height: m.height, under: m.under}
+	copy(filled.cells, m.cells)
+	visited := 0
+	var deadEnds []int
+	for p, c := range filled.cells {
+		if c == 0 && p != from && p != to && len(filled.openAround(p)) <= 1 {
+			deadEnds = append(deadEnds, p)
+		}
+	}
+	for len(deadEnds) > 0 {
+		p := deadEnds[len(deadEnds)-1]
+		deadEnds = deadEnds[:len(deadEnds)-1]
+		visited++
+		around := filled.openAround(p)
+		filled.cells[p] = 1
+		// the corridor may continue as a new dead end
+		for _, n := range around {
+			if n != from && n != to && len(filled.openAround(n)) <= 1 {
+				deadEnds = append(deadEnds, n)
+			}
+		}
+	}
+	s := bfs{}.Solve(&filled, from, to)
+	s.visited += visited
+	return s
+}
+
+// keeps the left hand on the wall. Works on every maze where the goal
+// is on the same wall as the start, like our corners on the outer border
+type wallFollower struct{}
+
+func (wallFollower) Solve(m *maze, from, to int) solution {
+	// index in the walk of every pixel, to erase the loops
+	seen := map[int]int{from: 0}
+	path := []int{from}
+	visited := 1
+	p, dir := from, 1 // start heading east
+	// in the middle of a room walk east until touching a wall,
+	// then turn to keep it on the left
+	for len(m.openAround(p)) == 4 && p != to {
+		p++
+		visited++
+		seen[p] = len(path)
+		path = append(path, p)
+	}
+	for turn := 0; turn < 4 && m.get(p%m.width+directions[(dir+3)%4].x, p/m.width+directions[(dir+3)%4].y) == 0; turn++ {
+		dir = (dir + 1) % 4
+	}
+	// every pixel can be entered at most from 4 directions
+	for steps := 0; p != to && steps < 4*len(m.cells); steps++ {
+		// try left, straight, right and back
+		for turn := 3; turn < 7; turn++ {
+			d := (dir + turn) % 4
+			if q, ok := m.next(p, directions[d]); ok {
+				dir = d
+				p = q
+				break
+			}
+		}
+		if i, ok := seen[p]; ok {
+			for _, q := range path[i+1:] {
+				delete(seen, q)
+			}
+			path = path[:i+1]
+			continue
+		}
+		visited++
+		seen[p] = len(path)
+		path = append(path, p)
+	}
+	if p != to {
+		return solution{visited: visited}
+	}
+	return solution{path: path, visited: visited}
+}
+
+// runs every solver on the same maze and writes a table
+func compareSolvers(w io.Writer, m *maze) {
+	fmt.Fprintf(w, "%-12s %8s %8s\n", "solver", "path", "visited")
+	for _, name := range solverNames() {
+		s := m.solve(name)
+		length := "no way"
+		if len(s.path) > 0 {
+			length = fmt.Sprint(len(s.path))
+		}
+		fmt.Fprintf(w, "%-12s %8s %8d\n", name, length, s.visited)
+	}
+}
+
+// a short description of the solution for the status line
+func (s solution) String() string {
+	if len(s.path) == 0 {
+		return fmt.Sprintf("no way out, visited %d", s.visited)
+	}
+	return fmt.Sprintf("path %d, visited %d", len(s.path), s.visited)
+}
+
+// the path as a set of pixels, to paint it over the maze
+func (s solution) pixels() map[int]bool {
+	result := make(map[int]bool, len(s.path))
+	for _, p := range s.path {
+		result[p] = true
+	}
+	return result
+}
@@ -0,0 +1,18 @@
+package main
+
+import "testing"
+
+// the wall follower finds the way on every grid, even with loops
+func TestWallFollowerShapes(t *testing.T) {
+	for _, grid := range []string{"hex", "triangle", "polar"} {
+		for seed := int64(1); seed <= 10; seed++ {
+			m := NewMaze(61, 41, options{algo: "growingtree", seed: seed, braid: 0.5, grid: grid})
+			if len(m.solve("bfs").path) == 0 {
+				t.Fatalf("%s seed %d: no way between the endpoints", grid, seed)
+			}
+			if len(m.solve("wallfollow").path) == 0 {
+				t.Errorf("%s seed %d: the wall follower found no way", grid, seed)
+			}
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// statistics work on the cells and the passages between them,
+// so they describe the structure of the maze and not its pixels
+
+// the measures of a single maze
+type mazeStats struct {
+	// fraction of cells with a single way in
+	deadEnds float64
+	// length of the longest shortest path, estimated with a double sweep
+	// (exact on perfect mazes, a lower bound when there are loops)
+	diameter float64
+	// average number of ways forward at the cells with 3 or more exits
+	branching float64
+	// average length of the corridors ending in a dead end: a high river
+	// means few long dead ends, a low one many short spurs
+	river float64
+	// number of straight runs of passages, by length in cells
+	straight map[int]int
+}
+
+// min, max and mean of a measure over many mazes
+type summary struct {
+	Mean float64 `json:"mean"`
+	Min  float64 `json:"min"`
+	Max  float64 `json:"max"`
+}
+
+// the report written by the stats command
+type statsReport struct {
+	Algo      string  `json:"algo"`
+	Grid      string  `json:"grid,omitempty"` // empty for the rectangle
+	Width     int     `json:"width"`
+	Height    int     `json:"height"`
+	Braid     float64 `json:"braid"`
+	Mazes     int     `json:"mazes"`
+	DeadEnds  summary `json:"dead_ends"`
+	Diameter  summary `json:"diameter"`
+	Branching summary `json:"branching"`
+	River     summary `json:"river"`
+	// fraction of the straight runs of each length
+	Straight map[int]float64 `json:"straight"`
+}
+
+func (m *maze) stats() mazeStats {
+	s := mazeStats{straight: map[int]int{}}
+	g := m.grid()
+	active := g.activeCells()
+	n := len(active)
+	if n == 0 {
+		return s
+	}
+	links := make([]int, g.cellCount())
+	for _, c := range active {
+		for _, nb := range g.neighbours(c) {
+			if g.linked(c, nb) {
+				links[c]++
+			}
+		}
+	}
+	var deadEnds, junctions, choices, spurs int
+	for _, c := range active {
+		switch l := links[c]; {
+		case l == 1:
+			deadEnds++
+			spurs += spurLength(g, c, links)
+		case l >= 3:
+			junctions++
+			choices += l - 1
+		}
+	}
+	s.deadEnds = float64(deadEnds) / float64(n)
+	if junctions > 0 {
+		s.branching = float64(choices) / float64(junctions)
+	}
+	if deadEnds > 0 {
+		s.river = float64(spurs) / float64(deadEnds)
+	}
+	far, _ := farthest(g, active[0])
+	_, s.diameter = farthest(g, far)
+	// runs only make sense on the rectangle
+	if m.shape == nil {
+		m.straightRuns(s.straight)
+	}
+	return s
+}
+
+// the cell farthest from start and its distance, following passages
+func farthest(g grid, start int) (int, float64) {
+	dist := make([]int, g.cellCount())
+	for i := range dist {
+		dist[i] = -1
+	}
+	dist[start] = 0
+	queue := []int{start}
+	last := start
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		last = c
+		for _, nb := range g.neighbours(c) {
+			if dist[nb] < 0 && g.linked(c, nb) {
+				dist[nb] = dist[c] + 1
+				queue = append(queue, nb)
+			}
+		}
+	}
+	return last, float64(dist[last])
+}
+
+// walks from a dead end until the first cell with more than two exits
+func spurLength(g grid, c int, links []int) int {
+	length, prev := 0, -1
+	for links[c] <= 2 {
+		next := -1
+		for _, nb := range g.neighbours(c) {
+			if nb != prev && g.linked(c, nb) {
+				next = nb
+				break
+			}
+		}
+		if next < 0 {
+			// the whole maze is a single corridor
+			break
+		}
+		prev, c = c, next
+		length++
+	}
+	return length
+}
+
+// counts the maximal horizontal and vertical runs of linked cells
+func (m *maze) straightRuns(runs map[int]int) {
+	cols, rows, g := m.cols(), m.rows(), m.grid()
+	count := func(length int) {
+		if length > 0 {
+			runs[length]++
+		}
+	}
+	for cy := 0; cy < rows; cy++ {
+		length := 0
+		for cx := 0; cx < cols-1; cx++ {
+			if g.linked(m.cellAt(cx, cy), m.cellAt(cx+1, cy)) {
+				length++
+				continue
+			}
+			count(length)
+			length = 0
+		}
+		count(length)
+	}
+	for cx := 0; cx < cols; cx++ {
+		length := 0
+		for cy := 0; cy < rows-1; cy++ {
+			if g.linked(m.cellAt(cx, cy), m.cellAt(cx, cy+1)) {
+				length++
+				continue
+			}
+			count(length)
+			length = 0
+		}
+		count(length)
+	}
+}
+
+// generates count mazes with consecutive seeds and summarizes them
+func collectStats(w, h int, opts options, count int) statsReport {
+	r := statsReport{Algo: opts.algo, Width: w, Height: h, Braid: opts.braid, Mazes: count, Straight: map[int]float64{}}
+	if opts.grid != "rect" {
+		r.Grid = opts.grid
+	}
+	var deadEnds, diameter, branching, river []float64
+	runs := map[int]int{}
+	for i := 0; i < count; i++ {
+		o := opts
+		o.seed += int64(i)
+		m := NewMaze(w, h, o)
+		s := m.stats()
+		deadEnds = append(deadEnds, s.deadEnds)
+		diameter = append(diameter, s.diameter)
+		branching = append(branching, s.branching)
+		river = append(river, s.river)
+		for length, n := range s.straight {
+			runs[length] += n
+		}
+	}
+	r.DeadEnds, r.Diameter = summarize(deadEnds), summarize(diameter)
+	r.Branching, r.River = summarize(branching), summarize(river)
+	total := 0
+	for _, n := range runs {
+		total += n
+	}
+	for length, n := range runs {
+		r.Straight[length] = float64(n) / float64(total)
+	}
+	return r
+}
+
+func summarize(values []float64) summary {
+	if len(values) == 0 {
+		return summary{}
+	}
+	s := summary{Min: math.Inf(1), Max: math.Inf(-1)}
+	for _, v := range values {
+		s.Mean += v / float64(len(values))
+		s.Min = min(s.Min, v)
+		s.Max = max(s.Max, v)
+	}
+	return s
+}
+
+// writes the report as JSON, or as a table for everything else
+func (r statsReport) write(w io.Writer, format string) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	}
+	grid := ""
+	if r.Grid != "" {
+		grid = " " + r.Grid
+	}
+	fmt.Fprintf(w, "%s%s %dx%d braid %g, %d mazes\n\n", r.Algo, grid, r.Width, r.Height, r.Braid, r.Mazes)
+	fmt.Fprintf(w, "%-12s %10s %10s %10s\n", "", "mean", "min", "max")
+	for _, row := range []struct {
+		name string
+		s    summary
+	}{
+		{"dead ends", r.DeadEnds},
+		{"diameter", r.Diameter},
+		{"branching", r.Branching},
+		{"river", r.River},
+	} {
+		fmt.Fprintf(w, "%-12s %10.3f %10.3f %10.3f\n", row.name, row.s.Mean, row.s.Min, row.s.Max)
+	}
+	fmt.Fprintf(w, "\nstraight runs\n")
+	lengths := make([]int, 0, len(r.Straight))
+	for length := range r.Straight {
+		lengths = append(lengths, length)
+	}
+	sort.Ints(lengths)
+	for _, length := range lengths {
+		_, err := fmt.Fprintf(w, "%12d %9.1f%%\n", length, 100*r.Straight[length])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
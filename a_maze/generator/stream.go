@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// text is written a line at a time through a buffer, so the text of a
+// huge maze never has to be in memory all at once
+
+// one char per pixel: '#' for walls and ' ' for passages
+type asciiChars struct{}
+
+func (asciiChars) Size() (int, int) { return 1, 1 }
+
+func (asciiChars) Glyph(bits uint) rune {
+	if bits&1 != 0 {
+		return '#'
+	}
+	return ' '
+}
+
+// the text formats that can be streamed and their chars
+var textRenderers = map[string]Renderer{
+	"ascii":   asciiChars{},
+	"blocks":  halfBlocks{},
+	"sextant": sextants{},
+	"braille": braille{},
+}
+
+// writes a w x h pixels maze with the chars of r, wall tells the filled pixels.
+// Every line ends with a newline
+func writeText(out io.Writer, w, h int, wall func(x, y int) bool, r Renderer) error {
+	buf := bufio.NewWriter(out)
+	bw, bh := r.Size()
+	for y := 0; y < h; y += bh {
+		for x := 0; x < w; x += bw {
+			var bits uint
+			for i := 0; i < bw*bh; i++ {
+				px, py := x+i%bw, y+i/bw
+				if px < w && py < h && wall(px, py) {
+					bits |= 1 << i
+				}
+			}
+			buf.WriteRune(r.Glyph(bits))
+		}
+		buf.WriteByte('\n')
+	}
+	return buf.Flush()
+}
+
+// generates a huge maze as bits and streams it to a file, "-" means
+// standard output. Only the bitGenerators and the text formats work
+func exportHuge(name, format string, w, h int, opts options) error {
+	r, ok := textRenderers[format]
+	generate, known := bitGenerators[opts.algo]
+	switch {
+	case !ok:
+		return fmt.Errorf("mazes bigger than %d pixels can only be written as ascii, blocks, sextant or braille", hugeMaze)
+	case !known || opts.grid != "rect" || opts.mask != nil || opts.braid != 0:
+		return fmt.Errorf("mazes bigger than %d pixels need -algo %s, -braid 0, the rect grid and no mask",
+			hugeMaze, strings.Join(bitGeneratorNames(), " or "))
+	}
+	g := generate(w, h, opts.seed)
+	wall := func(x, y int) bool { return g.get(x, y) == 1 }
+	return writeFile(name, func(out io.Writer) error {
+		return writeText(out, w, h, wall, r)
+	})
+}
+
+// creates a file and writes it, "-" means standard output
+func writeFile(name string, write func(io.Writer) error) error {
+	if name == "-" {
+		return write(os.Stdout)
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,56 @@
+package main
+
+// a maze bigger than the terminal shows only the part in the viewport,
+// and the keys pan it around. left and top are in pixels, aligned to the
+// chars of the renderer so every char keeps the same pixels while panning
+
+// pixels per char, horizontally and vertically.
+// Weave mazes move a cell at a time, like they're drawn
+func (m maze) charSize() (int, int) {
+	if m.under != nil {
+		return 2, 2
+	}
+	return m.chars().Size()
+}
+
+// the pixels on screen, all of them until the terminal size is known
+func (m maze) viewport() area {
+	if m.termWidth == 0 {
+		return m.whole()
+	}
+	bw, bh := m.chars().Size()
+	w, h := m.termWidth*bw, (m.termHeight-m.footerHeight())*bh
+	if m.under != nil {
+		// a char per pixel and a line every two
+		w, h = m.termWidth, (m.termHeight-m.footerHeight())*2
+	}
+	cw, ch := m.charSize()
+	// whole chars only, and at least one
+	w, h = max(w/cw, 1)*cw, max(h/ch, 1)*ch
+	left := clamp(m.left/cw*cw, 0, lastStart(m.width, w, cw))
+	top := clamp(m.top/ch*ch, 0, lastStart(m.height, h, ch))
+	return area{left, top, min(left+w, m.width), min(top+h, m.height)}
+}
+
+// true when the maze doesn't fit in the terminal
+func (m maze) scrolls() bool {
+	return m.viewport() != m.whole()
+}
+
+// moves the viewport by dx, dy chars
+func (m *maze) pan(dx, dy int) {
+	cw, ch := m.charSize()
+	v := m.viewport()
+	m.left = v.x0 + dx*cw
+	m.top = v.y0 + dy*ch
+}
+
+// the farthest the view of a size pixels can start, aligned to step.
+// The last char may be cut at the end of the maze
+func lastStart(total, size, step int) int {
+	return (max(0, total-size) + step - 1) / step * step
+}
+
+func clamp(v, low, high int) int {
+	return min(max(v, low), high)
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// a weave maze is a rectangular maze where passages can cross under each
+// other: a cell links to the cell two steps away, going under the one in
+// between, if that one is a straight corridor in the other direction.
+// On the pixels a crossing looks like any junction, so the maze remembers
+// the direction of the passage below in under, see next()
+type weave struct {
+	*maze
+}
+
+// only the algorithms linking a cell as soon as they find it: the others
+// pick their links in advance, and a crossing may be gone when they carve it
+var weaveGenerators = []string{"aldousbroder", "backtracker", "growingtree"}
+
+// true if the passage below the crossing runs east-west
+func (m *maze) crossing(c int) (horizontal, ok bool) {
+	x, y := m.pixelXY(c)
+	horizontal, ok = m.under[y*m.width+x]
+	return horizontal, ok
+}
+
+// the cell in between and the one after it, moving two steps from c.
+// Returns false at the border of the maze
+func (w weave) across(c int, d struct{ x, y int }) (int, int, bool) {
+	cx, cy := w.cellXY(c)
+	fx, fy := cx+2*d.x, cy+2*d.y
+	if fx < 0 || fy < 0 || fx >= w.cols() || fy >= w.rows() {
+		return 0, 0, false
+	}
+	return w.cellAt(cx+d.x, cy+d.y), w.cellAt(fx, fy), true
+}
+
+// true if a passage can go under mid in the direction d:
+// mid must be a straight corridor across it, not crossed yet
+func (w weave) canTunnel(mid int, d struct{ x, y int }) bool {
+	if _, crossed := w.crossing(mid); crossed {
+		return false
+	}
+	// the cells on the two sides of mid, across d
+	cx, cy := w.cellXY(mid)
+	ax, ay, bx, by := cx+d.y, cy+d.x, cx-d.y, cy-d.x
+	if ax < 0 || ay < 0 || bx < 0 || by < 0 || ax >= w.cols() || ay >= w.rows() || bx >= w.cols() || by >= w.rows() {
+		return false
+	}
+	a, b := w.cellAt(ax, ay), w.cellAt(bx, by)
+	links := 0
+	for _, n := range w.maze.neighbours(mid) {
+		if w.maze.linked(mid, n) {
+			links++
+		}
+	}
+	return links == 2 && w.maze.linked(mid, a) && w.maze.linked(mid, b)
+}
+
+// the adjacent cells, but not the crossings from the side,
+// and the cells on the other side of a crossing
+func (w weave) neighbours(c int) []int {
+	var result []int
+	for _, n := range w.maze.neighbours(c) {
+		if h, ok := w.crossing(n); !ok || h != w.horizontal(c, n) {
+			result = append(result, n)
+		}
+	}
+	for _, d := range directions {
+		mid, far, ok := w.across(c, d)
+		if !ok || !w.active(far) {
+			continue
+		}
+		// an existing passage below, or one that can be dug
+		if h, crossed := w.crossing(mid); crossed && h == (d.y == 0) || w.canTunnel(mid, d) {
+			result = append(result, far)
+		}
+	}
+	return result
+}
+
+// true if b is east or west of a
+func (w weave) horizontal(a, b int) bool {
+	_, ay := w.cellXY(a)
+	_, by := w.cellXY(b)
+	return ay == by
+}
+
+// the cell in between if a and b are two steps apart in a line
+func (w weave) between(a, b int) (int, bool) {
+	ax, ay := w.cellXY(a)
+	bx, by := w.cellXY(b)
+	if (ax == bx && abs(ay-by) == 2) || (ay == by && abs(ax-bx) == 2) {
+		return w.cellAt((ax+bx)/2, (ay+by)/2), true
+	}
+	return 0, false
+}
+
+// a link two steps apart digs a passage below the cell in between
+func (w weave) link(a, b int) {
+	mid, ok := w.between(a, b)
+	if !ok {
+		w.maze.link(a, b)
+		return
+	}
+	w.maze.link(a, mid)
+	w.maze.link(mid, b)
+	x, y := w.pixelXY(mid)
+	w.under[y*w.width+x] = w.horizontal(a, b)
+}
+
+func (w weave) linked(a, b int) bool {
+	if mid, ok := w.between(a, b); ok {
+		h, crossed := w.crossing(mid)
+		return crossed && h == w.horizontal(a, b)
+	}
+	// the open wall next to a crossing belongs to the passage below
+	for _, c := range []int{a, b} {
+		if h, ok := w.crossing(c); ok && h == w.horizontal(a, b) {
+			return false
+		}
+	}
+	return w.maze.linked(a, b)
+}
+
+// the pixel reached moving from p in the direction d: crossings are passed
+// below along their lower passage, and can't be left sideways
+func (m *maze) next(p int, d struct{ x, y int }) (int, bool) {
+	horizontal := d.y == 0
+	if h, ok := m.under[p]; ok && h == horizontal {
+		return 0, false
+	}
+	x, y := p%m.width+d.x, p/m.width+d.y
+	if h, ok := m.under[y*m.width+x]; ok && h == horizontal {
+		x, y = x+d.x, y+d.y
+	}
+	if m.get(x, y) != 0 {
+		return 0, false
+	}
+	return y*m.width + x, true
+}
+
+// the passages drawn with box lines, two chars per cell: the cell
+// with its ways out and the way east. The upper passage of a crossing
+// is heavy, so the one below looks interrupted.
+// paint works like in render, with the pixels of the cells and of the walls.
+// Without paint the text has no colors at all.
+// Only the cells in a are drawn, a char for each pixel and a line every two
+func (m maze) passages(a area, paint func(x, y int) lipgloss.TerminalColor) string {
+	var sb, run strings.Builder
+	var runColor lipgloss.TerminalColor
+	flush := func() {
+		if paint == nil {
+			sb.WriteString(run.String())
+		} else if runColor != nil {
+			sb.WriteString(baseStyle.Foreground(runColor).Render(run.String()))
+		} else {
+			sb.WriteString(baseStyle.Render(run.String()))
+		}
+		run.Reset()
+	}
+	write := func(r rune, x, y int) {
+		var c lipgloss.TerminalColor
+		if paint != nil {
+			c = paint(x, y)
+		}
+		if c != runColor && run.Len() > 0 {
+			flush()
+		}
+		runColor = c
+		run.WriteRune(r)
+	}
+	cy1, cx1 := min(m.rows(), a.y1/2), min(m.cols(), a.x1/2)
+	for cy := a.y0 / 2; cy < cy1; cy++ {
+		for cx := a.x0 / 2; cx < cx1; cx++ {
+			x, y := 2*cx+1, 2*cy+1
+			write(m.passageGlyph(x, y), x, y)
+			way := ' '
+			if cx < m.cols()-1 && m.get(x+1, y) == 0 {
+				way = '─'
+			}
+			write(way, x+1, y)
+		}
+		flush()
+		if cy < cy1-1 {
+			sb.WriteRune('\n')
+		}
+	}
+	return sb.String()
+}
+
+// indexed by the open sides of a cell: 1 north, 2 east, 4 south, 8 west
+var passageGlyphs = [16]rune{' ', '╵', '╶', '└', '╷', '│', '┌', '├', '╴', '┘', '─', '┴', '┐', '┤', '┬', '┼'}
+
+func (m maze) passageGlyph(x, y int) rune {
+	if m.get(x, y) != 0 {
+		return ' '
+	}
+	if h, ok := m.under[y*m.width+x]; ok {
+		if h {
+			return '┃'
+		}
+		return '━'
+	}
+	bits := 0
+	for i, d := range directions {
+		if m.get(x+d.x, y+d.y) == 0 {
+			bits |= 1 << i
+		}
+	}
+	// directions go clockwise, the bits are north, east, south, west
+	return passageGlyphs[bits]
+}